@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/alexflint/go-arg"
+)
+
+// subcommands maps subcommand names to their entry points
+var subcommands = map[string]func(argv []string){
+	"export":    runExport,
+	"get":       runGet,
+	"import":    runImport,
+	"mv":        runMv,
+	"rm":        runRm,
+	"set":       runSet,
+	"translate": runTranslate,
+	"tui":       runTUI,
+}
+
+// parseSubcommand parses the arguments of a subcommand into dest
+func parseSubcommand(name string, dest interface{}, argv []string) {
+	p, err := arg.NewParser(arg.Config{
+		Program:   "i18nedt " + name,
+		EnvPrefix: "I18NEDT_",
+	}, dest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	p.MustParse(argv)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/kikyous/i18nedt/internal/diff"
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// exitNotFound is the exit code of get and rm for keys that don't exist.
+// Errors exit with 1 and invalid arguments with 2.
+const exitNotFound = 3
+
+// assignment matches a LOCALE=VALUE argument of set; values may span lines
+var assignment = regexp.MustCompile(`(?s)^([A-Za-z]{2,3}(?:[-_][A-Za-z0-9]+)*)=(.*)$`)
+
+func runSet(argv []string) {
+	var setArgs struct {
+		Key       string   `arg:"positional,required" help:"Key to set, prefixed with its namespace if any"`
+		Args      []string `arg:"positional" help:"LOCALE=VALUE assignments, followed by the target file paths [env: I18NEDT_FILES]"`
+		JSON      bool     `arg:"--json" help:"Parse values as JSON, e.g. objects, arrays or numbers"`
+		DryRun    bool     `arg:"-n,--dry-run" help:"Show the changes as a diff without writing them"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+	}
+	parseSubcommand("set", &setArgs, argv)
+
+	values, patterns, err := parseAssignments(setArgs.Args, setArgs.JSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	sources, _ := discoverSources(patterns, !setArgs.DryRun)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+	files, _, err = i18n.CreateMissingNamespaces(files, sources, []string{setArgs.Key}, setArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Set values directly, so keys are created even with an empty value
+	locales := make([]string, 0, len(values))
+	for locale := range values {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	for _, locale := range locales {
+		found, err := editor.SetKey(files, setArgs.Key, locale, values[locale], setArgs.Separator)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !found {
+			fmt.Fprintf(os.Stderr, "Error: no file for locale %s to hold %s\n", locale, setArgs.Key)
+			os.Exit(1)
+		}
+	}
+
+	saveWithoutPrompts(files, setArgs.DryRun)
+}
+
+// parseAssignments splits the arguments of set into the values of its
+// LOCALE=VALUE assignments and file patterns. Other arguments holding a "="
+// must match a file, so a mistyped assignment is not taken for a new file.
+func parseAssignments(args []string, asJSON bool) (map[string]*types.Value, []string, error) {
+	values := make(map[string]*types.Value)
+	var patterns []string
+	for _, a := range args {
+		m := assignment.FindStringSubmatch(a)
+		if m == nil {
+			if strings.Contains(a, "=") {
+				if matches, _ := doublestar.FilepathGlob(i18n.PatternToGlob(a)); len(matches) == 0 {
+					return nil, nil, fmt.Errorf("%q is neither a LOCALE=VALUE assignment nor an existing file", a)
+				}
+			}
+			patterns = append(patterns, a)
+			continue
+		}
+		value := types.NewStringValue(m[2])
+		if asJSON {
+			if !gjson.Valid(m[2]) {
+				return nil, nil, fmt.Errorf("invalid JSON value for locale %s: %s", m[1], m[2])
+			}
+			value = types.NewJSONValue(m[2])
+		}
+		values[m[1]] = value
+	}
+	if len(values) == 0 {
+		return nil, nil, fmt.Errorf("no LOCALE=VALUE assignments given")
+	}
+	return values, patterns, nil
+}
+
+func runGet(argv []string) {
+	var getArgs struct {
+		Key       string   `arg:"positional,required" help:"Key to print, prefixed with its namespace if any"`
+		Locale    string   `arg:"-l,--locale" help:"Only print the value of this locale"`
+		JSON      bool     `arg:"--json" help:"Print JSON: the value with --locale, otherwise an object of values by locale"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files     []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("get", &getArgs, argv)
+
+	sources, _ := discoverSources(getArgs.Files, false)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+	values, err := editor.LookupKey(files, getArgs.Key, getArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if getArgs.Locale != "" {
+		raw, ok := values[getArgs.Locale]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Key %s not found in locale %s\n", getArgs.Key, getArgs.Locale)
+			os.Exit(exitNotFound)
+		}
+		if getArgs.JSON {
+			fmt.Println(compactJSON(raw))
+		} else {
+			fmt.Println(plainValue(raw))
+		}
+		return
+	}
+
+	if len(values) == 0 {
+		fmt.Fprintf(os.Stderr, "Key %s not found\n", getArgs.Key)
+		os.Exit(exitNotFound)
+	}
+	if getArgs.JSON {
+		byLocale := make(map[string]json.RawMessage, len(values))
+		for locale, raw := range values {
+			byLocale[locale] = json.RawMessage(compactJSON(raw))
+		}
+		data, err := json.Marshal(byLocale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	locales := make([]string, 0, len(values))
+	for locale := range values {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	for _, locale := range locales {
+		fmt.Printf("%s=%s\n", locale, plainValue(values[locale]))
+	}
+}
+
+func runRm(argv []string) {
+	var rmArgs struct {
+		Key       string   `arg:"positional,required" help:"Key to delete with its children, prefixed with its namespace if any"`
+		DryRun    bool     `arg:"-n,--dry-run" help:"Show the changes as a diff without writing them"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files     []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("rm", &rmArgs, argv)
+
+	sources, _ := discoverSources(rmArgs.Files, !rmArgs.DryRun)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+	values, err := editor.LookupKey(files, rmArgs.Key, rmArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(values) == 0 {
+		fmt.Fprintf(os.Stderr, "Key %s not found\n", rmArgs.Key)
+		os.Exit(exitNotFound)
+	}
+
+	tempFile := &types.TempFile{
+		Content:   make(map[string]map[string]*types.Value),
+		Deletes:   []string{rmArgs.Key},
+		Separator: rmArgs.Separator,
+	}
+	if err := editor.ApplyChanges(files, tempFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error applying changes: %v\n", err)
+		os.Exit(1)
+	}
+
+	saveWithoutPrompts(files, rmArgs.DryRun)
+}
+
+// saveWithoutPrompts saves the changed files, failing instead of asking when
+// they were changed on disk in the meantime. With dryRun the changes are
+// shown as a diff instead.
+func saveWithoutPrompts(files []*types.I18nFile, dryRun bool) {
+	if dryRun {
+		diffs, err := diff.Compute(files)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printDiff(diffs)
+		return
+	}
+
+	savedCount, err := i18n.SaveAllFiles(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving files: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully updated %d files\n", savedCount)
+}
+
+// plainValue renders a raw JSON value for output: strings without quotes,
+// everything else as compact JSON
+func plainValue(raw string) string {
+	if result := gjson.Parse(raw); result.Type == gjson.String {
+		return result.String()
+	}
+	return compactJSON(raw)
+}
+
+func compactJSON(raw string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		return raw
+	}
+	return buf.String()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestParseAssignments(t *testing.T) {
+	dir := t.TempDir()
+	odd := filepath.Join(dir, "a=b.json")
+	if err := os.WriteFile(odd, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		args         []string
+		json         bool
+		wantValues   map[string]*types.Value
+		wantPatterns []string
+		wantErr      string
+	}{
+		{
+			name:         "multi-line value",
+			args:         []string{"en-US=a\nb", "de-DE=x", "*.json"},
+			wantValues:   map[string]*types.Value{"en-US": types.NewStringValue("a\nb"), "de-DE": types.NewStringValue("x")},
+			wantPatterns: []string{"*.json"},
+		},
+		{
+			name:       "empty value",
+			args:       []string{"en="},
+			wantValues: map[string]*types.Value{"en": types.NewStringValue("")},
+		},
+		{
+			name:       "JSON value",
+			args:       []string{"en={\n  \"a\": 1\n}"},
+			json:       true,
+			wantValues: map[string]*types.Value{"en": types.NewJSONValue("{\n  \"a\": 1\n}")},
+		},
+		{
+			name:         "existing file with an equals sign",
+			args:         []string{"en=x", odd},
+			wantValues:   map[string]*types.Value{"en": types.NewStringValue("x")},
+			wantPatterns: []string{odd},
+		},
+		{name: "invalid JSON", args: []string{"en={"}, json: true, wantErr: "invalid JSON"},
+		{name: "mistyped assignment", args: []string{"en-US!=x"}, wantErr: "neither a LOCALE=VALUE assignment nor an existing file"},
+		{name: "no assignments", args: []string{"*.json"}, wantErr: "no LOCALE=VALUE assignments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, patterns, err := parseAssignments(tt.args, tt.json)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseAssignments() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAssignments() error = %v", err)
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) || !reflect.DeepEqual(patterns, tt.wantPatterns) {
+				t.Errorf("parseAssignments() = %v, %v, want %v, %v", values, patterns, tt.wantValues, tt.wantPatterns)
+			}
+		})
+	}
+}
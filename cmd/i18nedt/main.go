@@ -1,16 +1,23 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/alexflint/go-arg"
+	"github.com/kikyous/i18nedt/internal/diff"
 	"github.com/kikyous/i18nedt/internal/doctor"
 	"github.com/kikyous/i18nedt/internal/editor"
 	"github.com/kikyous/i18nedt/internal/flatten"
 	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/tm"
 	"github.com/kikyous/i18nedt/pkg/types"
 )
 
@@ -23,20 +30,47 @@ var (
 
 // args struct for go-arg
 var args struct {
-	Keys      []string `arg:"-k,--key,separate" help:"Key to edit (can be specified multiple times)"`
-	PrintOnly bool     `arg:"-p,--print" help:"Print temporary file content without launching editor"`
-	NoTips    bool     `arg:"-a,--no-tips,env" help:"Exclude AI tips from temporary file content"`
-	Doctor    bool     `arg:"-d,--doctor" help:"Check for missing and empty keys"`
-	Flatten   bool     `arg:"-f,--flatten" help:"Flatten JSON files to key=value format"`
-	Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
-	Version   bool     `arg:"-v,--version" help:"Show version information"`
-	Files     []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	Keys           []string `arg:"-k,--key,separate" help:"Key to edit (can be specified multiple times)"`
+	PrintOnly      bool     `arg:"-p,--print" help:"Print temporary file content without launching editor"`
+	NoTips         bool     `arg:"-a,--no-tips,env" help:"Exclude AI tips from temporary file content"`
+	Doctor         bool     `arg:"-d,--doctor" help:"Check for missing and empty keys"`
+	Fix            bool     `arg:"--fix" help:"With --doctor, edit all missing and empty keys in a single temporary file"`
+	FillFromTM     bool     `arg:"--fill-from-tm" help:"Fill empty values with exact matches from the translation memory"`
+	Flatten        bool     `arg:"-f,--flatten" help:"Flatten JSON files to key=value format"`
+	Separator      string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+	Source         []string `arg:"--source,separate" help:"Source files to scan for unused and undefined keys with --doctor (can be specified multiple times)"`
+	Functions      []string `arg:"--func,separate" help:"Translation function to look for with --source (default: t, $t, i18n.t)"`
+	SourceLocale   string   `arg:"--source-locale,env:SOURCE_LOCALE" help:"Locale that translations are compared against with --doctor, and of Android default resources in values/"`
+	Placeholders   string   `arg:"--placeholders,env:PLACEHOLDERS" help:"Comma separated placeholder syntaxes compared with --source-locale: i18next, icu, printf, vue (default: i18next,icu)"`
+	AllowIdentical []string `arg:"--allow-identical,separate" help:"Value that may be identical to the source locale, like a brand name (can be specified multiple times)"`
+	Format         string   `arg:"--format" default:"text" help:"Doctor report format: text, json, sarif, junit or github"`
+	Baseline       bool     `arg:"--baseline" help:"Only report doctor issues missing from the baseline file, and drop fixed ones from it"`
+	BaselineFile   string   `arg:"--baseline-file,env:BASELINE_FILE" help:"Baseline file used by --baseline (default: .i18nedt-baseline.json)"`
+	WriteBaseline  string   `arg:"--write-baseline" help:"Record the current doctor issues in a baseline file"`
+	Resume         string   `arg:"-r,--resume" help:"Apply a temporary file left over from a previous session"`
+	Apply          string   `arg:"--apply" help:"Apply a temporary file without an editor, - reads it from stdin"`
+	DryRun         bool     `arg:"-n,--dry-run" help:"Show the changes as a diff after editing instead of saving them"`
+	Confirm        bool     `arg:"--confirm" help:"Show the changes as a diff after editing and ask before saving them"`
+	Version        bool     `arg:"-v,--version" help:"Show version information"`
+	Files          []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
 }
 
 func main() {
+	// Subcommands have their own flags and are dispatched before parsing
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
 	p, err := arg.NewParser(arg.Config{
 		EnvPrefix: "I18NEDT_",
 	}, &args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	// Parse command line arguments
 	p.MustParse(os.Args[1:])
 
@@ -52,23 +86,51 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Handle file expansion (globbing) via discovery module
-	sources, flatFiles, err := i18n.DiscoverFiles(args.Files)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// With --apply -, stdin holds the temporary file, not answers to prompts
+	if args.Apply == "-" {
+		promptFromTerminal()
+	}
+
+	// Android default resources in values/ are in the source locale
+	if args.SourceLocale != "" {
+		i18n.AndroidDefaultLocale = args.SourceLocale
 	}
 
+	// Only editing and fixing write files, not printing or previewing them
+	writes := !args.Flatten && !args.PrintOnly && !args.DryRun && (!args.Doctor || args.Fix)
+	sources, flatFiles := discoverSources(args.Files, writes)
+
 	// Construct Config
 	config := &types.Config{
-		Files:     flatFiles, // We keep this for Flatten logic which iterates simple paths
-		Keys:      args.Keys,
-		Editor:    os.Getenv("EDITOR"),
-		PrintOnly: args.PrintOnly,
-		NoTips:    args.NoTips,
-		Flatten:   args.Flatten,
-		Doctor:    args.Doctor,
-		Separator: args.Separator,
+		Files:          flatFiles, // We keep this for Flatten logic which iterates simple paths
+		Keys:           args.Keys,
+		Editor:         os.Getenv("EDITOR"),
+		PrintOnly:      args.PrintOnly,
+		NoTips:         args.NoTips,
+		Flatten:        args.Flatten,
+		Doctor:         args.Doctor,
+		Fix:            args.Fix,
+		FillFromTM:     args.FillFromTM,
+		Separator:      args.Separator,
+		Resume:         args.Resume,
+		Apply:          args.Apply,
+		DryRun:         args.DryRun,
+		Confirm:        args.Confirm,
+		Source:         args.Source,
+		Functions:      args.Functions,
+		SourceLocale:   args.SourceLocale,
+		AllowIdentical: args.AllowIdentical,
+		Format:         args.Format,
+		WriteBaseline:  args.WriteBaseline,
+	}
+	if args.Baseline {
+		config.Baseline = args.BaselineFile
+		if config.Baseline == "" {
+			config.Baseline = doctor.DefaultBaselinePath
+		}
+	}
+	if args.Placeholders != "" {
+		config.Placeholders = strings.Split(args.Placeholders, ",")
 	}
 	if config.Editor == "" {
 		config.Editor = "vim"
@@ -76,7 +138,7 @@ func main() {
 
 	// Handle doctor mode
 	if config.Doctor {
-		runDoctor(sources, config.Flatten, config.Separator)
+		runDoctor(sources, config)
 		return
 	}
 
@@ -86,16 +148,98 @@ func main() {
 		return
 	}
 
+	// Handle apply mode, which needs no editor
+	if config.Apply != "" {
+		runApply(config, sources)
+		return
+	}
+
 	// Validate editor
 	if err := editor.ValidateEditor(config.Editor); err != nil {
 		fmt.Fprintf(os.Stderr, "Editor error: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Handle resume mode
+	if config.Resume != "" {
+		runResume(config, sources)
+		return
+	}
+
 	runEditor(config, sources)
 }
 
-func runDoctor(sources []types.FileSource, simple bool, separator string) {
+// discoverSources expands file patterns into sources. Modes that write files
+// first deal with saves to them that were interrupted in a previous run, the
+// others only warn about them.
+func discoverSources(patterns []string, writes bool) ([]types.FileSource, []string) {
+	// Handle file expansion (globbing) via discovery module
+	sources, flatFiles, err := i18n.DiscoverFiles(patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	journals := i18n.FindJournals(sourcePaths(sources))
+	if len(journals) == 0 {
+		return sources, flatFiles
+	}
+	if !writes {
+		for _, journal := range journals {
+			fmt.Fprintf(os.Stderr, "Warning: a previous save was interrupted (%s found), files may be partly saved. Run i18nedt to edit them to complete or roll it back.\n", journal)
+		}
+		return sources, flatFiles
+	}
+
+	// Finish or undo the interrupted saves. Completing one may create files,
+	// so the patterns are expanded again.
+	for _, journal := range journals {
+		recoverJournal(journal)
+	}
+	sources, flatFiles, err = i18n.DiscoverFiles(patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return sources, flatFiles
+}
+
+func sourcePaths(sources []types.FileSource) []string {
+	paths := make([]string, len(sources))
+	for i, source := range sources {
+		paths[i] = source.Path
+	}
+	return paths
+}
+
+// recoverJournal asks whether the interrupted save recorded in journal should
+// be completed or rolled back. Without an answer, nothing is done and
+// i18nedt stops rather than writing over the interrupted save.
+func recoverJournal(journal string) {
+	fmt.Fprintf(os.Stderr, "A previous save was interrupted (%s found).\n", journal)
+	switch choose("(C)omplete it or (r)oll all files back?", "cr") {
+	case 'c':
+		if err := i18n.CompleteJournal(journal); err != nil {
+			fmt.Fprintf(os.Stderr, "Error completing interrupted save: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Completed interrupted save")
+	case 'r':
+		if err := i18n.RollbackJournal(journal); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rolling back interrupted save: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Rolled back interrupted save")
+	default:
+		fmt.Fprintln(os.Stderr, "Error: no answer, the interrupted save was left as it is. Run i18nedt in a terminal to complete or roll it back.")
+		os.Exit(1)
+	}
+}
+
+func runDoctor(sources []types.FileSource, config *types.Config) {
+	// A baseline matched by a broad pattern like *.json is not a locale file
+	sources = withoutFiles(sources, config.Baseline, config.WriteBaseline)
+
 	// Load all i18n files
 	files, err := i18n.LoadAllFiles(sources)
 	if err != nil {
@@ -103,7 +247,51 @@ func runDoctor(sources []types.FileSource, simple bool, separator string) {
 		os.Exit(1)
 	}
 
-	foundIssues, err := doctor.Run(files, simple, separator)
+	opts := doctor.Options{
+		Separator:      config.Separator,
+		SourceLocale:   config.SourceLocale,
+		Placeholders:   config.Placeholders,
+		AllowIdentical: config.AllowIdentical,
+		Format:         config.Format,
+	}
+	if err := doctor.ValidatePlaceholderSyntaxes(opts.Placeholders); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Compare against the keys used in application code
+	if len(config.Source) > 0 {
+		refs, err := doctor.ScanSources(config.Source, config.Functions, config.Separator)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning source files: %v\n", err)
+			os.Exit(1)
+		}
+		result, err := doctor.CheckUsage(files, refs, config.Separator)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error running doctor check: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Usage = &result
+	}
+
+	if config.Fix {
+		runFix(files, config, opts)
+		return
+	}
+	if config.WriteBaseline != "" {
+		writeBaseline(files, config.WriteBaseline, opts)
+		return
+	}
+	if config.Baseline != "" {
+		baseline, err := doctor.LoadBaseline(config.Baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (create it with --write-baseline)\n", err)
+			os.Exit(1)
+		}
+		opts.Baseline = baseline
+	}
+
+	foundIssues, err := doctor.Run(files, config.Flatten, opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running doctor check: %v\n", err)
 		os.Exit(1)
@@ -114,6 +302,77 @@ func runDoctor(sources []types.FileSource, simple bool, separator string) {
 	}
 }
 
+// withoutFiles returns sources without the given paths
+func withoutFiles(sources []types.FileSource, paths ...string) []types.FileSource {
+	var kept []types.FileSource
+	for _, source := range sources {
+		excluded := false
+		for _, path := range paths {
+			if path != "" && filepath.Clean(source.Path) == filepath.Clean(path) {
+				excluded = true
+			}
+		}
+		if !excluded {
+			kept = append(kept, source)
+		}
+	}
+	return kept
+}
+
+// runFix opens the keys the doctor reports as missing or empty in the editor
+func runFix(files []*types.I18nFile, config *types.Config, opts doctor.Options) {
+	results, err := doctor.CheckWithOptions(files, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running doctor check: %v\n", err)
+		os.Exit(1)
+	}
+
+	tempFile, err := doctor.FixTempFile(files, results, config.SourceLocale, config.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating temporary file: %v\n", err)
+		os.Exit(1)
+	}
+	if tempFile == nil {
+		fmt.Println("No missing or empty keys to fix.")
+		return
+	}
+	suggestions := suggestFromMemory(files, tempFile, config)
+
+	if config.PrintOnly {
+		content, err := editor.GenerateTempFileContentWithOptions(tempFile, config.NoTips)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating temporary file content: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(content))
+		return
+	}
+
+	if err := editor.ValidateEditor(config.Editor); err != nil {
+		fmt.Fprintf(os.Stderr, "Editor error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := editAndSave(files, tempFile, config.Editor, config.NoTips, review{DryRun: config.DryRun, Confirm: config.Confirm, Suggest: suggestions}); err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+}
+
+// writeBaseline records the issues currently found by the doctor in path
+func writeBaseline(files []*types.I18nFile, path string, opts doctor.Options) {
+	results, err := doctor.CheckWithOptions(files, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running doctor check: %v\n", err)
+		os.Exit(1)
+	}
+
+	baseline := doctor.NewBaseline(path, doctor.Issues(results, opts.Usage, opts.Separator))
+	if err := baseline.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Recorded %d issues in %s\n", len(baseline.Issues), path)
+}
+
 func runFlatten(sources []types.FileSource, separator string) {
 	// Load all i18n files
 	files, err := i18n.LoadAllFiles(sources)
@@ -130,15 +389,18 @@ func runFlatten(sources []types.FileSource, separator string) {
 			os.Exit(1)
 		}
 
-		// Sort keys for consistent output
+		// Print plain dotted keys, sorted for consistent output
 		keys := make([]string, 0, len(flat))
-		for k := range flat {
-			keys = append(keys, k)
+		values := make(map[string]string, len(flat))
+		for k, v := range flat {
+			key := flatten.UnescapeKey(k)
+			keys = append(keys, key)
+			values[key] = v
 		}
 		sort.Strings(keys)
 
 		for _, k := range keys {
-			fmt.Printf("%s = %s\n", k, flat[k])
+			fmt.Printf("%s = %s\n", k, values[k])
 		}
 	}
 }
@@ -167,6 +429,8 @@ func runEditor(config *types.Config, sources []types.FileSource) {
 		os.Exit(1)
 	}
 
+	suggestions := suggestFromMemory(files, tempFile, config)
+
 	// If print only mode, generate content and print to stdout
 	if config.PrintOnly {
 		content, err := editor.GenerateTempFileContentWithOptions(tempFile, config.NoTips)
@@ -178,45 +442,407 @@ func runEditor(config *types.Config, sources []types.FileSource) {
 		return
 	}
 
-	// Ensure cleanup on exit
-	defer func() {
-		if err := editor.CleanupTempFile(tempFile); err != nil {
-			log.Printf("Warning: failed to cleanup temporary file: %v", err)
+	if err := editAndSave(files, tempFile, config.Editor, config.NoTips, review{DryRun: config.DryRun, Confirm: config.Confirm, Suggest: suggestions}); err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+}
+
+// suggestFromMemory adds translation memory suggestions for the empty values
+// of tempFile, filling in exact matches if configured. The returned suggester
+// looks them up again after editing, nil if the memory is unavailable.
+func suggestFromMemory(files []*types.I18nFile, tempFile *types.TempFile, config *types.Config) *suggester {
+	s := &suggester{
+		files:     files,
+		separator: config.Separator,
+		fill:      config.FillFromTM,
+		context:   make(map[string]string, len(tempFile.Context)),
+	}
+	for key, context := range tempFile.Context {
+		s.context[key] = context
+	}
+	if !s.annotate(tempFile) {
+		return nil
+	}
+	return s
+}
+
+// suggester adds translation memory suggestions to a temporary file
+type suggester struct {
+	files     []*types.I18nFile
+	separator string
+	fill      bool
+	context   map[string]string // Context of the temporary file without suggestions
+	shown     map[string]string // Context with the suggestions last written
+}
+
+// annotate replaces the suggestions in tempFile with the current ones,
+// learning the texts written in it, and reports whether that worked
+func (s *suggester) annotate(tempFile *types.TempFile) bool {
+	memory, err := tm.Build(s.files, s.separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: translation memory unavailable: %v\n", err)
+		return false
+	}
+	memory.Learn(tempFile)
+
+	tempFile.Context = make(map[string]string, len(s.context))
+	for key, context := range s.context {
+		tempFile.Context[key] = context
+	}
+	if filled := memory.Annotate(tempFile, s.fill); filled > 0 {
+		fmt.Fprintf(os.Stderr, "Filled %d values from the translation memory\n", filled)
+	}
+	return true
+}
+
+// update looks up the values left empty in the edited tempFile again, as
+// source texts may have been written for new keys in the meantime. It reports
+// whether that found suggestions that were not shown yet.
+func (s *suggester) update(tempFile *types.TempFile) bool {
+	if s.shown == nil {
+		s.shown = tempFile.Context
+	}
+	if !s.annotate(tempFile) {
+		return false
+	}
+
+	found := false
+	for key, context := range tempFile.Context {
+		shown := strings.Split(s.shown[key], "\n")
+		for _, line := range strings.Split(context, "\n") {
+			if !slices.Contains(shown, line) {
+				found = true
+			}
 		}
-	}()
+	}
+	s.shown = tempFile.Context
+	return found
+}
 
+// editAndSave lets the user edit the temporary file and writes the result,
+// after showing the changes if r asks for it. Once edited, the temporary file
+// is kept on errors, so the edits can be resumed.
+func editAndSave(files []*types.I18nFile, tempFile *types.TempFile, editorCmd string, noTips bool, r review) error {
 	// Write initial content to temporary file
-	if err := editor.WriteTempFileWithOptions(tempFile, config.NoTips); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing temporary file: %v\n", err)
-		os.Exit(1)
+	if err := editor.WriteTempFileWithOptions(tempFile, noTips); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
 	}
 
 	// Open editor
-	if err := editor.OpenEditor(tempFile.Path, config.Editor); err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening editor: %v\n", err)
+	if err := editor.OpenEditor(tempFile.Path, editorCmd); err != nil {
+		editor.CleanupTempFile(tempFile)
+		return fmt.Errorf("failed to open editor: %w", err)
+	}
+
+	// Parse edited content, reopening the editor on parse errors
+	if err := editUntilValid(tempFile, editorCmd); err != nil {
+		return fmt.Errorf("failed to parse edited file: %w", err)
+	}
+
+	// Source texts written for new keys may have translations elsewhere
+	if r.Suggest != nil && r.Suggest.update(tempFile) {
+		if err := editor.WriteTempFileWithOptions(tempFile, noTips); err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+		if confirm("The translation memory has new suggestions for empty values. Reopen the editor to review them?", true) {
+			if err := editor.OpenEditor(tempFile.Path, editorCmd); err != nil {
+				return fmt.Errorf("failed to open editor: %w", err)
+			}
+			if err := editUntilValid(tempFile, editorCmd); err != nil {
+				return fmt.Errorf("failed to parse edited file: %w", err)
+			}
+		}
+	}
+
+	save, err := reviewChanges(files, tempFile, editorCmd, r)
+	if err != nil || !save {
+		return err
+	}
+	return applyAndSave(files, tempFile)
+}
+
+func runResume(config *types.Config, sources []types.FileSource) {
+	// Load all i18n files
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Parse edited content
-	if err := editor.ReadTempFile(tempFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing edited file: %v\n", err)
+	locales, _ := i18n.GetLocaleList(files)
+	tempFile := &types.TempFile{
+		Path:      config.Resume,
+		Locales:   locales,
+		Separator: config.Separator,
+	}
+
+	// Parse the left-over file, offering to fix it in the editor if it is broken
+	if err := editUntilValid(tempFile, config.Editor); err != nil {
+		exitKeepingTempFile(tempFile, fmt.Errorf("failed to parse resumed file: %w", err))
+	}
+
+	// Create namespaces referenced by the resumed file that don't exist yet
+	files = createNamespacesFor(files, sources, tempFile)
+
+	save, err := reviewChanges(files, tempFile, config.Editor, review{DryRun: config.DryRun, Confirm: config.Confirm})
+	if err == nil && save {
+		err = applyAndSave(files, tempFile)
+	}
+	if err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+}
+
+// runApply applies a temporary file read from a file or stdin, for pipelines
+// like `i18nedt -p -k home | llm | i18nedt --apply -`. Only --confirm asks,
+// on the terminal, before saving.
+func runApply(config *types.Config, sources []types.FileSource) {
+	var content []byte
+	var err error
+	if config.Apply == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(config.Apply)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", config.Apply, err)
 		os.Exit(1)
 	}
 
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	locales, _ := i18n.GetLocaleList(files)
+	tempFile, err := editor.ParseTempFileContent(unwrapCodeBlock(string(content)), locales)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", config.Apply, err)
+		os.Exit(1)
+	}
+	tempFile.Locales = locales
+	tempFile.Separator = config.Separator
+
+	files = createNamespacesFor(files, sources, tempFile)
+
+	if config.DryRun {
+		if _, err := reviewChanges(files, tempFile, config.Editor, review{DryRun: true}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// The applied file has no path to edit again, so it is saved or cancelled
+	if config.Confirm {
+		diffs, err := diff.Preview(files, tempFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to apply changes: %v\n", err)
+			os.Exit(1)
+		}
+		printDiff(diffs)
+		if len(diffs) > 0 && !confirm("Save these changes?", false) {
+			fmt.Fprintln(os.Stderr, "Error: save cancelled: changes not confirmed")
+			os.Exit(1)
+		}
+	}
+
+	if err := saveChanges(files, tempFile, true); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// unwrapCodeBlock removes a code block around the whole content, which
+// language models like to add to their answers
+func unwrapCodeBlock(content string) string {
+	trimmed := strings.TrimSpace(content)
+	first, rest, ok := strings.Cut(trimmed, "\n")
+	if !ok || !strings.HasPrefix(first, "```") {
+		return content
+	}
+	if body, ok := strings.CutSuffix(rest, "```"); ok && strings.HasSuffix(body, "\n") {
+		return body
+	}
+	return content
+}
+
+// createNamespacesFor creates the files of namespaces that keys in tempFile
+// refer to and that don't exist yet
+func createNamespacesFor(files []*types.I18nFile, sources []types.FileSource, tempFile *types.TempFile) []*types.I18nFile {
+	keys := make([]string, 0, len(tempFile.Content)+len(tempFile.Renames))
+	for key := range tempFile.Content {
+		keys = append(keys, key)
+	}
+	for _, r := range tempFile.Renames {
+		keys = append(keys, r.To)
+	}
+	files, createdNs, err := i18n.CreateMissingNamespaces(files, sources, keys, tempFile.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, ns := range createdNs {
+		fmt.Printf("Creating new namespace: %s\n", ns)
+	}
+	return files
+}
+
+// editUntilValid parses the temporary file. On parse errors the error is
+// annotated in the file and the user may reopen the editor to fix it.
+func editUntilValid(tempFile *types.TempFile, editorCmd string) error {
+	for {
+		err := editor.ReadTempFile(tempFile)
+		if err == nil {
+			return nil
+		}
+
+		var perr *editor.ParseError
+		if !errors.As(err, &perr) {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Error parsing edited file: %v\n", perr)
+		if !confirm("Reopen the editor to fix it?", true) {
+			return err
+		}
+
+		if err := editor.AnnotateTempFile(tempFile, perr); err != nil {
+			return err
+		}
+		if err := editor.OpenEditor(tempFile.Path, editorCmd); err != nil {
+			return err
+		}
+	}
+}
+
+// exitKeepingTempFile reports the error and exits, leaving the temporary file on disk for --resume
+func exitKeepingTempFile(tempFile *types.TempFile, err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if path := keptTempFile(tempFile); path != "" {
+		fmt.Fprintf(os.Stderr, "Your edits were kept in %s\n", path)
+		fmt.Fprintf(os.Stderr, "Apply them later with: i18nedt --resume %s <files>\n", path)
+	}
+	os.Exit(1)
+}
+
+// keptTempFile returns the path of the temporary file if it is still on disk
+func keptTempFile(tempFile *types.TempFile) string {
+	if tempFile.Path == "" {
+		return ""
+	}
+	if _, err := os.Stat(tempFile.Path); err != nil {
+		return ""
+	}
+	return tempFile.Path
+}
+
+// resolveConflict asks the user which value to keep for a key that was changed
+// both on disk and in the editor
+func resolveConflict(file string, c i18n.MergeConflict) bool {
+	fmt.Fprintf(os.Stderr, "\n%s was changed on disk while editing.\n", file)
+	fmt.Fprintf(os.Stderr, "Conflict on key %s:\n", c.Key)
+	fmt.Fprintf(os.Stderr, "  original: %s\n", describeRaw(c.Base))
+	fmt.Fprintf(os.Stderr, "  on disk:  %s\n", describeRaw(c.Theirs))
+	fmt.Fprintf(os.Stderr, "  yours:    %s\n", describeRaw(c.Ours))
+	return confirm("Keep your value?", true)
+}
+
+// describeRaw renders a raw JSON value from a merge for display
+func describeRaw(raw string) string {
+	if raw == "" {
+		return "(deleted)"
+	}
+	return raw
+}
+
+// introducedTypeIssues returns the keys whose type differs between locales in
+// files but did not before, with their types, as "key: conflict"
+func introducedTypeIssues(files []*types.I18nFile, separator string, before map[string][]doctor.TypeIssue) []string {
+	after, err := doctor.CheckTypes(files, separator)
+	if err != nil {
+		return nil
+	}
+
+	known := make(map[string]bool)
+	for _, issues := range before {
+		for _, issue := range issues {
+			known[issue.Key] = true
+		}
+	}
+	conflicts := make(map[string]string)
+	for _, issues := range after {
+		for _, issue := range issues {
+			if !known[issue.Key] {
+				conflicts[issue.Key] = issue.Key + ": " + issue.Conflict
+			}
+		}
+	}
+
+	introduced := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		introduced = append(introduced, c)
+	}
+	sort.Strings(introduced)
+	return introduced
+}
+
+// applyAndSave writes the parsed temporary file into the i18n files and removes it on success
+func applyAndSave(files []*types.I18nFile, tempFile *types.TempFile) error {
+	if err := saveChanges(files, tempFile, false); err != nil {
+		return err
+	}
+	if err := editor.CleanupTempFile(tempFile); err != nil {
+		log.Printf("Warning: failed to cleanup temporary file: %v", err)
+	}
+	return nil
+}
+
+// saveChanges applies tempFile to files, warns about value types it makes
+// differ between locales and saves the files. Unless nonInteractive, such a
+// mismatch and conflicts with changes on disk are asked about; otherwise the
+// warning is only printed and conflicts fail the save.
+func saveChanges(files []*types.I18nFile, tempFile *types.TempFile, nonInteractive bool) error {
+	before, _ := doctor.CheckTypes(files, tempFile.Separator)
+
 	// Apply changes to the actual files
 	if err := editor.ApplyChanges(files, tempFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error applying changes: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+
+	// Warn about keys whose type no longer agrees across locales
+	if introduced := introducedTypeIssues(files, tempFile.Separator, before); len(introduced) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: this save makes value types differ between locales:")
+		for _, issue := range introduced {
+			fmt.Fprintf(os.Stderr, "  %s\n", issue)
+		}
+		if !nonInteractive && !confirm("Save anyway?", true) {
+			return errors.New("save cancelled: type mismatch between locales")
+		}
 	}
 
 	// Save all files
-	savedCount, err := i18n.SaveAllFiles(files)
+	resolve := resolveConflict
+	if nonInteractive {
+		resolve = nil
+	}
+	savedCount, err := i18n.SaveAllFilesWithResolver(files, resolve)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error saving files: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to save files: %w", err)
 	}
 
-	// Report summary
+	reportChanges(tempFile, savedCount)
+	return nil
+}
+
+// reportChanges prints a summary of the changes in tempFile
+func reportChanges(tempFile *types.TempFile, savedCount int) {
+	if len(tempFile.Renames) > 0 {
+		fmt.Printf("Renamed %d keys\n", len(tempFile.Renames))
+		for _, r := range tempFile.Renames {
+			fmt.Printf("  %s -> %s\n", r.From, r.To)
+		}
+	}
 	if len(tempFile.Deletes) > 0 {
 		fmt.Printf("Deleted %d keys\n", len(tempFile.Deletes))
 		for _, key := range tempFile.Deletes {
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kikyous/i18nedt/internal/doctor"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func runMv(argv []string) {
+	var mvArgs struct {
+		From      string   `arg:"positional,required" help:"Key to rename, prefixed with its namespace if any"`
+		To        string   `arg:"positional,required" help:"New key, prefixed with its namespace if any"`
+		Source    []string `arg:"--source,separate" help:"Source files whose references to the key are rewritten (can be specified multiple times)"`
+		Functions []string `arg:"--func,separate" help:"Translation function to look for with --source (default: t, $t, i18n.t)"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files     []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("mv", &mvArgs, argv)
+
+	sources, _ := discoverSources(mvArgs.Files, true)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Find the references before changing anything, so a bad pattern stops the move
+	var changes []doctor.SourceChange
+	if len(mvArgs.Source) > 0 {
+		changes, err = doctor.RenameInSources(mvArgs.Source, mvArgs.Functions, mvArgs.Separator, mvArgs.From, mvArgs.To)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error scanning source files: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// The key may move to a namespace that doesn't exist yet
+	files, createdNs, err := i18n.CreateMissingNamespaces(files, sources, []string{mvArgs.To}, mvArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, ns := range createdNs {
+		fmt.Printf("Creating new namespace: %s\n", ns)
+	}
+
+	tempFile := &types.TempFile{
+		Content:   make(map[string]map[string]*types.Value),
+		Deletes:   []string{},
+		Renames:   []types.KeyRename{{From: mvArgs.From, To: mvArgs.To}},
+		Separator: mvArgs.Separator,
+	}
+	if err := applyAndSave(files, tempFile); err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+
+	for _, change := range changes {
+		info, err := os.Stat(change.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", change.Path, err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(change.Path, []byte(change.Content), info.Mode().Perm()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating %s: %v\n", change.Path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Updated %d references in %s\n", change.Count, change.Path)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// stdinIsInput is set when stdin is read as input, like the XLIFF file of
+// import -, so prompts are answered from the terminal instead
+var stdinIsInput bool
+
+// promptFromTerminal reads the answers to prompts from the terminal, as stdin
+// is read as input. Without a terminal, prompts fail instead of reading EOF
+// and cancelling silently.
+func promptFromTerminal() {
+	stdinIsInput = true
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		stdinReader = bufio.NewReader(tty)
+	} else {
+		stdinReader = bufio.NewReader(strings.NewReader(""))
+	}
+}
+
+// readAnswer reads the answer to question, failing if it can't be asked
+// because stdin is the input and there is no terminal
+func readAnswer(question string) (string, error) {
+	answer, err := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if err != nil && answer == "" && stdinIsInput {
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintf(os.Stderr, "Error: cannot ask %q: stdin is the input and no terminal is available\n", question)
+		os.Exit(1)
+	}
+	return answer, err
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// An empty answer selects the default; EOF (no terminal) always answers no.
+// Answers are read from the terminal when stdin is the input, see
+// promptFromTerminal.
+func confirm(question string, defaultYes bool) bool {
+	hint := "[y/N]"
+	if defaultYes {
+		hint = "[Y/n]"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s ", question, hint)
+
+	answer, err := readAnswer(question)
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	if answer == "" {
+		return defaultYes
+	}
+	return answer == "y" || answer == "yes"
+}
+
+// choose asks a question answered with one of the letters in choices, the
+// first being the default, and returns the answer. Other answers repeat the
+// question; EOF (no terminal) answers 0.
+func choose(question, choices string) byte {
+	hint := strings.ToUpper(choices[:1])
+	for _, c := range choices[1:] {
+		hint += "/" + string(c)
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "%s [%s] ", question, hint)
+
+		answer, err := readAnswer(question)
+		if err != nil && answer == "" {
+			fmt.Fprintln(os.Stderr)
+			return 0
+		}
+		if answer == "" {
+			return choices[0]
+		}
+		if strings.IndexByte(choices, answer[0]) >= 0 {
+			return answer[0]
+		}
+	}
+}
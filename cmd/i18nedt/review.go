@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kikyous/i18nedt/internal/diff"
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// review selects whether edits are shown before they are saved
+type review struct {
+	DryRun  bool // Show the changes and keep the temporary file instead of saving
+	Confirm bool // Show the changes and ask before saving
+
+	// Offers translation memory suggestions for values still empty after
+	// editing, if set
+	Suggest *suggester
+}
+
+// printDiff shows the changes saving would write
+func printDiff(diffs []diff.FileDiff) {
+	if len(diffs) == 0 {
+		fmt.Println("No changes")
+		return
+	}
+	fmt.Print(diff.Format(diffs))
+	fmt.Println(diff.Summary(diffs))
+}
+
+// reviewChanges shows the changes tempFile makes to files as a diff, and with
+// Confirm asks whether to save them, cancel or edit them again. It returns
+// false if the edits must not be saved, and an error if they were cancelled.
+func reviewChanges(files []*types.I18nFile, tempFile *types.TempFile, editorCmd string, r review) (bool, error) {
+	if !r.DryRun && !r.Confirm {
+		return true, nil
+	}
+
+	for {
+		diffs, err := diff.Preview(files, tempFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to apply changes: %w", err)
+		}
+		printDiff(diffs)
+
+		if r.DryRun {
+			fmt.Println("Dry run, no files were changed")
+			if tempFile.Path != "" {
+				fmt.Printf("Your edits were kept in %s\n", tempFile.Path)
+				fmt.Printf("Apply them with: i18nedt --resume %s <files>\n", tempFile.Path)
+			}
+			return false, nil
+		}
+		if len(diffs) == 0 {
+			return true, nil
+		}
+
+		switch choose("Save these changes? (y)es, (n)o, (e)dit again", "yne") {
+		case 'y':
+			return true, nil
+		case 'e':
+			if err := editor.OpenEditor(tempFile.Path, editorCmd); err != nil {
+				return false, fmt.Errorf("failed to open editor: %w", err)
+			}
+			if err := editUntilValid(tempFile, editorCmd); err != nil {
+				return false, fmt.Errorf("failed to parse edited file: %w", err)
+			}
+		default:
+			return false, errors.New("save cancelled: changes not confirmed")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/translate"
+)
+
+func runTranslate(argv []string) {
+	var translateArgs struct {
+		Keys      []string      `arg:"-k,--key,separate" help:"Key to translate with its children (default: all keys)"`
+		From      string        `arg:"--from,required" help:"Locale to translate from"`
+		To        string        `arg:"--to,required" help:"Comma separated locales to translate into"`
+		Provider  string        `arg:"--provider,env:PROVIDER" default:"openai" help:"Translation provider: openai, deepl or command"`
+		Endpoint  string        `arg:"--endpoint,env:ENDPOINT" help:"Base URL of the provider API (default: the official API)"`
+		APIKey    string        `arg:"--api-key,env:API_KEY" help:"API key of the provider"`
+		Model     string        `arg:"--model,env:MODEL" help:"Model used by the openai provider (default: gpt-4o-mini)"`
+		Command   string        `arg:"--command,env:TRANSLATE_COMMAND" help:"Command used by the command provider"`
+		Batch     int           `arg:"--batch" default:"50" help:"Number of texts sent per request"`
+		Timeout   time.Duration `arg:"--timeout" default:"2m" help:"Timeout of a single request"`
+		PrintOnly bool          `arg:"-p,--print" help:"Print the translations as a temporary file instead of saving them"`
+		Separator string        `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files     []string      `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("translate", &translateArgs, argv)
+
+	// Fall back to the variables the providers document
+	apiKey := translateArgs.APIKey
+	if apiKey == "" {
+		switch translateArgs.Provider {
+		case "openai":
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		case "deepl":
+			apiKey = os.Getenv("DEEPL_AUTH_KEY")
+		}
+	}
+	provider, err := translate.NewProvider(translate.Config{
+		Provider: translateArgs.Provider,
+		Endpoint: translateArgs.Endpoint,
+		APIKey:   apiKey,
+		Model:    translateArgs.Model,
+		Command:  translateArgs.Command,
+		Timeout:  translateArgs.Timeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sources, _ := discoverSources(translateArgs.Files, !translateArgs.PrintOnly)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	targets := strings.Split(translateArgs.To, ",")
+	for _, locale := range targets {
+		if i18n.FindFileByLocale(files, locale) == nil {
+			fmt.Fprintf(os.Stderr, "Error: no files found for target locale %s\n", locale)
+			os.Exit(1)
+		}
+	}
+
+	jobs, err := translate.Collect(files, translateArgs.Keys, translateArgs.From, targets, translateArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	progress := func(to string, done, total int) {
+		fmt.Fprintf(os.Stderr, "Translated %d/%d keys into %s\n", done, total, to)
+	}
+	tempFile, err := translate.Run(context.Background(), provider, translateArgs.From, jobs, translateArgs.Batch, translateArgs.Separator, progress)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(tempFile.Content) == 0 {
+		fmt.Println("Nothing to translate")
+		return
+	}
+
+	if translateArgs.PrintOnly {
+		tempFile.Locales = targets
+		content, err := editor.GenerateTempFileContentWithOptions(tempFile, true)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating temporary file content: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(string(content))
+		return
+	}
+
+	// Keys may belong to namespaces that don't exist in a target locale yet
+	files, createdNs, err := i18n.CreateMissingNamespaces(files, sources, tempFile.Keys, translateArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, ns := range createdNs {
+		fmt.Printf("Creating new namespace: %s\n", ns)
+	}
+
+	if err := applyAndSave(files, tempFile); err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+}
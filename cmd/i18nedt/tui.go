@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/tui"
+)
+
+// runTUI implements "i18nedt tui": browse keys, edit cells inline and hand
+// selections to $EDITOR
+func runTUI(argv []string) {
+	var cmd struct {
+		NoTips    bool     `arg:"-a,--no-tips,env" help:"Exclude AI tips from temporary file content"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files     []string `arg:"positional,required" help:"Target file paths"`
+	}
+	parseSubcommand("tui", &cmd, argv)
+
+	editorCmd := os.Getenv("EDITOR")
+	if editorCmd == "" {
+		editorCmd = "vim"
+	}
+
+	sources, _ := discoverSources(cmd.Files, true)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	state := &tui.State{}
+	for {
+		result, err := tui.Run(files, cmd.Separator, state)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		switch result.Action {
+		case tui.Quit:
+			return
+
+		case tui.Save:
+			// Errors are shown in the browser, keeping the inline edits
+			savedCount, err := i18n.SaveAllFilesWithResolver(files, resolveConflict)
+			if err != nil {
+				state.Status = fmt.Sprintf("Error saving files: %v", err)
+				continue
+			}
+			for _, file := range files {
+				file.Dirty = false
+			}
+			state.Status = fmt.Sprintf("Successfully updated %d files", savedCount)
+
+		case tui.Edit:
+			if err := editor.ValidateEditor(editorCmd); err != nil {
+				state.Status = fmt.Sprintf("Editor error: %v", err)
+				continue
+			}
+
+			// Inline edits are saved together with the editor's changes. If
+			// that fails, the files go back to their inline edits.
+			snapshot := i18n.CloneFiles(files)
+
+			files, _, err = i18n.CreateMissingNamespaces(files, sources, result.Keys, cmd.Separator)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			tempFile, err := editor.CreateTempFile(files, result.Keys, cmd.Separator)
+			if err != nil {
+				state.Status = fmt.Sprintf("Error creating temporary file: %v", err)
+				continue
+			}
+
+			if err := editAndSave(files, tempFile, editorCmd, cmd.NoTips, review{}); err != nil {
+				files = snapshot
+				state.Status = fmt.Sprintf("Error: %v", err)
+				if path := keptTempFile(tempFile); path != "" {
+					state.Status += fmt.Sprintf(" (edits kept in %s)", path)
+				}
+				continue
+			}
+			for _, file := range files {
+				file.Dirty = false
+			}
+			state.Selected = nil
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/xliff"
+)
+
+func runExport(argv []string) {
+	var exportArgs struct {
+		Format       string   `arg:"--format" default:"xliff" help:"Export format (xliff)"`
+		XliffVersion string   `arg:"--xliff-version" default:"1.2" help:"XLIFF version: 1.2 or 2.0"`
+		Source       string   `arg:"--source,required" help:"Source locale"`
+		Target       string   `arg:"--target,required" help:"Target locale"`
+		Keys         []string `arg:"-k,--key,separate" help:"Key to export with its children (default: all keys)"`
+		Output       string   `arg:"-o,--output" help:"Write to file instead of stdout"`
+		Separator    string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Files        []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("export", &exportArgs, argv)
+
+	if exportArgs.Format != "xliff" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported export format %s\n", exportArgs.Format)
+		os.Exit(1)
+	}
+
+	sources, _ := discoverSources(exportArgs.Files, false)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+
+	doc, err := xliff.Collect(files, exportArgs.Keys, exportArgs.Source, exportArgs.Target, exportArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error collecting keys: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := xliff.Marshal(doc, exportArgs.XliffVersion)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if exportArgs.Output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(exportArgs.Output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", exportArgs.Output, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d units to %s\n", len(doc.Units), exportArgs.Output)
+}
+
+func runImport(argv []string) {
+	var importArgs struct {
+		Format    string   `arg:"--format" default:"xliff" help:"Import format (xliff)"`
+		Separator string   `arg:"-s,--separator,env:SEPARATOR" default:":" help:"Namespace separator (default: ':')"`
+		Input     string   `arg:"positional,required" help:"File to import, - for stdin"`
+		Files     []string `arg:"positional" help:"Target file paths [env: I18NEDT_FILES]"`
+	}
+	parseSubcommand("import", &importArgs, argv)
+
+	if importArgs.Format != "xliff" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported import format %s\n", importArgs.Format)
+		os.Exit(1)
+	}
+
+	var data []byte
+	var err error
+	if importArgs.Input == "-" {
+		promptFromTerminal()
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(importArgs.Input)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", importArgs.Input, err)
+		os.Exit(1)
+	}
+
+	doc, err := xliff.Unmarshal(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if doc.TargetLocale == "" {
+		fmt.Fprintf(os.Stderr, "Error: %s does not declare a target language\n", importArgs.Input)
+		os.Exit(1)
+	}
+
+	sources, _ := discoverSources(importArgs.Files, true)
+	files, err := i18n.LoadAllFiles(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading files: %v\n", err)
+		os.Exit(1)
+	}
+	if i18n.FindFileByLocale(files, doc.TargetLocale) == nil {
+		fmt.Fprintf(os.Stderr, "Error: no files found for target locale %s\n", doc.TargetLocale)
+		os.Exit(1)
+	}
+
+	tempFile, skipped := xliff.ToTempFile(doc, importArgs.Separator)
+	if len(skipped) > 0 {
+		fmt.Printf("Skipped %d units that are not translated yet\n", len(skipped))
+	}
+
+	// Units may reference namespaces that don't exist in this locale yet
+	files, createdNs, err := i18n.CreateMissingNamespaces(files, sources, tempFile.Keys, importArgs.Separator)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, ns := range createdNs {
+		fmt.Printf("Creating new namespace: %s\n", ns)
+	}
+
+	if err := applyAndSave(files, tempFile); err != nil {
+		exitKeepingTempFile(tempFile, err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package diff describes the changes a save would write to the i18n files,
+// key by key, and renders them as a colored unified diff.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// Kind is the kind of change made to a key
+type Kind int
+
+const (
+	Added Kind = iota
+	Changed
+	Deleted
+)
+
+// Change is a changed key with its old and new raw JSON values
+type Change struct {
+	Key  string // Flattened key within the file
+	Kind Kind
+	Old  string // Empty if added
+	New  string // Empty if deleted
+}
+
+// FileDiff lists the changes to one locale of a file
+type FileDiff struct {
+	Path    string
+	Locale  string
+	Changes []Change
+}
+
+var (
+	fileStyle    = lipgloss.NewStyle().Bold(true)
+	hunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+	deletedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+)
+
+// Compute returns the changes between the data of the dirty files as loaded
+// from disk and their current data, sorted by path, locale and key
+func Compute(files []*types.I18nFile) ([]FileDiff, error) {
+	var diffs []FileDiff
+	for _, file := range files {
+		if !file.Dirty || file.Data == file.Original {
+			continue
+		}
+		original := file.Original
+		if original == "" {
+			original = "{}"
+		}
+		before, err := flatten.FlattenJSON([]byte(original), "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+		after, err := flatten.FlattenJSON([]byte(file.Data), "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+
+		var changes []Change
+		for key, old := range before {
+			value, ok := after[key]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Key: key, Kind: Deleted, Old: old})
+			case value != old:
+				changes = append(changes, Change{Key: key, Kind: Changed, Old: old, New: value})
+			}
+		}
+		for key, value := range after {
+			if _, ok := before[key]; !ok {
+				changes = append(changes, Change{Key: key, Kind: Added, New: value})
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+		diffs = append(diffs, FileDiff{Path: file.Path, Locale: file.Locale, Changes: changes})
+	}
+
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Path != diffs[j].Path {
+			return diffs[i].Path < diffs[j].Path
+		}
+		return diffs[i].Locale < diffs[j].Locale
+	})
+	return diffs, nil
+}
+
+// Preview returns the changes saving files would write once temp is applied,
+// without changing files
+func Preview(files []*types.I18nFile, temp *types.TempFile) ([]FileDiff, error) {
+	copies := i18n.CloneFiles(files)
+	if err := editor.ApplyChanges(copies, temp); err != nil {
+		return nil, err
+	}
+	return Compute(copies)
+}
+
+// Format renders diffs as a unified diff with a hunk per locale and a line per
+// key. Colors are only used if the terminal supports them.
+func Format(diffs []FileDiff) string {
+	var b strings.Builder
+	for i, d := range diffs {
+		if i == 0 || diffs[i-1].Path != d.Path {
+			b.WriteString(fileStyle.Render("--- "+d.Path) + "\n")
+			b.WriteString(fileStyle.Render("+++ "+d.Path) + "\n")
+		}
+		b.WriteString(hunkStyle.Render("@@ "+d.Locale+" @@") + "\n")
+		for _, c := range d.Changes {
+			if c.Kind != Added {
+				b.WriteString(deletedStyle.Render(fmt.Sprintf("-%s: %s", c.Key, c.Old)) + "\n")
+			}
+			if c.Kind != Deleted {
+				b.WriteString(addedStyle.Render(fmt.Sprintf("+%s: %s", c.Key, c.New)) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Summary counts the changes in diffs, as "2 added, 1 changed, 0 deleted in 2 files"
+func Summary(diffs []FileDiff) string {
+	counts := make(map[Kind]int)
+	paths := make(map[string]bool)
+	for _, d := range diffs {
+		paths[d.Path] = true
+		for _, c := range d.Changes {
+			counts[c.Kind]++
+		}
+	}
+	return fmt.Sprintf("%d added, %d changed, %d deleted in %d files", counts[Added], counts[Changed], counts[Deleted], len(paths))
+}
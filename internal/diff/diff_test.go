@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestCompute(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "de.json", Locale: "de", Original: `{"a":"Alt","b":"B","c":{"d":"D"}}`, Data: `{"a":"Neu","c":{"d":"D"},"e":1}`, Dirty: true},
+		{Path: "en.json", Locale: "en", Original: `{"a":"A"}`, Data: `{"a":"A"}`, Dirty: true},
+		{Path: "fr.json", Locale: "fr", Original: `{"a":"A"}`, Data: `{"a":"B"}`}, // Not saved
+		{Path: "new.json", Locale: "de", Data: `{"x":"X"}`, Dirty: true},
+	}
+
+	got, err := Compute(files)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	want := []FileDiff{
+		{Path: "de.json", Locale: "de", Changes: []Change{
+			{Key: "a", Kind: Changed, Old: `"Alt"`, New: `"Neu"`},
+			{Key: "b", Kind: Deleted, Old: `"B"`},
+			{Key: "e", Kind: Added, New: "1"},
+		}},
+		{Path: "new.json", Locale: "de", Changes: []Change{
+			{Key: "x", Kind: Added, New: `"X"`},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compute() = %+v, want %+v", got, want)
+	}
+
+	if got, want := Summary(got), "2 added, 1 changed, 1 deleted in 2 files"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Original: `{"a":"A","old":"O"}`, Data: `{"a":"A","old":"O"}`},
+	}
+	temp := &types.TempFile{
+		Content:   map[string]map[string]*types.Value{"a": {"en": types.NewStringValue("B")}},
+		Deletes:   []string{"old"},
+		Separator: ":",
+	}
+
+	got, err := Preview(files, temp)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if len(got) != 1 || len(got[0].Changes) != 2 {
+		t.Errorf("Preview() = %+v, want a change and a deletion", got)
+	}
+	if files[0].Data != `{"a":"A","old":"O"}` || files[0].Dirty {
+		t.Errorf("Preview() changed the files: %+v", files[0])
+	}
+}
+
+func TestPreviewKeepsFormatState(t *testing.T) {
+	tests := []struct {
+		file     string
+		content  string
+		metadata string // Metadata of a, which must not reach b
+	}{
+		{"app_en.arb", `{"@@locale":"en","a":"A","@a":{"description":"Desc A"}}`, "Desc A"},
+		{"en.po", "#. Desc A\nmsgid \"a\"\nmsgstr \"A\"\n", "Desc A"},
+		{"Localizable.xcstrings", `{"sourceLanguage":"en","strings":{"a":{"comment":"Desc A"}},"version":"1.0"}`, "Desc A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			files, err := i18n.LoadAllFiles([]types.FileSource{{Path: path}})
+			if err != nil {
+				t.Fatalf("LoadAllFiles() error = %v", err)
+			}
+
+			// Preview a rename and discard it
+			rename := &types.TempFile{Renames: []types.KeyRename{{From: "a", To: "b"}}, Separator: ":"}
+			if _, err := Preview(files, rename); err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+
+			// A new b is unrelated to a, even once a is deleted
+			create := &types.TempFile{
+				Content:   map[string]map[string]*types.Value{"b": {"en": types.NewStringValue("B")}},
+				Deletes:   []string{"a"},
+				Separator: ":",
+			}
+			if err := editor.ApplyChanges(files, create); err != nil {
+				t.Fatalf("ApplyChanges() error = %v", err)
+			}
+			if _, err := i18n.SaveAllFiles(files); err != nil {
+				t.Fatalf("SaveAllFiles() error = %v", err)
+			}
+			got, _ := os.ReadFile(path)
+			if strings.Contains(string(got), tt.metadata) {
+				t.Errorf("saved after a discarded preview = %s, want no %q", got, tt.metadata)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	diffs := []FileDiff{
+		{Path: "app.xcstrings", Locale: "de", Changes: []Change{{Key: "a", Kind: Changed, Old: `"Alt"`, New: `"Neu"`}}},
+		{Path: "app.xcstrings", Locale: "fr", Changes: []Change{{Key: "b", Kind: Deleted, Old: `"B"`}}},
+		{Path: "en.json", Locale: "en", Changes: []Change{{Key: "c", Kind: Added, New: `"C"`}}},
+	}
+
+	// Tests don't run in a terminal, so there are no colors
+	want := `--- app.xcstrings
++++ app.xcstrings
+@@ de @@
+-a: "Alt"
++a: "Neu"
+@@ fr @@
+-b: "B"
+--- en.json
++++ en.json
+@@ en @@
++c: "C"
+`
+	if got := Format(diffs); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// DefaultBaselinePath is where the baseline is kept unless configured otherwise
+const DefaultBaselinePath = ".i18nedt-baseline.json"
+
+// Baseline records known issues, so that only new ones are reported
+type Baseline struct {
+	Version int             `json:"version"`
+	Issues  []BaselineEntry `json:"issues"`
+
+	path string
+}
+
+// BaselineEntry identifies an issue independently of its position and
+// message, which change as files are edited. The locale tells apart the
+// locales of files holding several, like .xcstrings catalogs; entries
+// without one match every locale of the file.
+type BaselineEntry struct {
+	Rule   string `json:"rule"`
+	File   string `json:"file"`
+	Locale string `json:"locale,omitempty"`
+	Key    string `json:"key"`
+}
+
+func entryOf(issue Issue) BaselineEntry {
+	return BaselineEntry{Rule: issue.Rule, File: issue.File, Locale: issue.Locale, Key: issue.Key}
+}
+
+// anyLocale returns e matching every locale of its file
+func (e BaselineEntry) anyLocale() BaselineEntry {
+	e.Locale = ""
+	return e
+}
+
+// NewBaseline creates a baseline at path recording issues
+func NewBaseline(path string, issues []Issue) *Baseline {
+	b := &Baseline{Version: 1, Issues: []BaselineEntry{}, path: path}
+	seen := make(map[BaselineEntry]bool)
+	for _, issue := range issues {
+		if e := entryOf(issue); !seen[e] {
+			seen[e] = true
+			b.Issues = append(b.Issues, e)
+		}
+	}
+	b.sort()
+	return b
+}
+
+// LoadBaseline reads a baseline written by Save
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	b := &Baseline{path: path}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != 1 {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+	return b, nil
+}
+
+// Save writes the baseline back to its path
+func (b *Baseline) Save() error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(b.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Path returns the file the baseline is read from and saved to
+func (b *Baseline) Path() string {
+	return b.path
+}
+
+// Contains reports whether the issue is recorded in the baseline
+func (b *Baseline) Contains(issue Issue) bool {
+	e := entryOf(issue)
+	for _, known := range b.Issues {
+		if known == e || known == e.anyLocale() {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope is what a doctor run looked at: the rules it evaluated and the files
+// it loaded. Prune leaves entries outside of it alone.
+type Scope struct {
+	Rules map[string]bool
+	Files map[string]bool
+}
+
+// ScopeOf returns the scope of a run of opts over files
+func ScopeOf(files []*types.I18nFile, opts Options) Scope {
+	s := Scope{
+		Rules: map[string]bool{"missing-key": true, "empty-value": true, "fuzzy-translation": true, "type-mismatch": true},
+		Files: make(map[string]bool),
+	}
+	if opts.SourceLocale != "" {
+		// Placeholders are only fully compared with a source locale
+		s.Rules["orphan-key"] = true
+		s.Rules["untranslated"] = true
+		s.Rules["placeholder-mismatch"] = true
+	}
+	syntaxes := opts.Placeholders
+	if len(syntaxes) == 0 {
+		syntaxes = DefaultPlaceholderSyntaxes
+	}
+	for _, syntax := range syntaxes {
+		if syntax == "icu" {
+			s.Rules["icu-syntax"] = true
+			s.Rules["plural-categories"] = true
+		}
+	}
+	if opts.Usage != nil {
+		s.Rules["unused-key"] = true
+		s.Rules["undefined-key"] = true
+	}
+	for _, file := range files {
+		s.Files[file.Path] = true
+	}
+	return s
+}
+
+// covers reports whether the issue of e was looked for. Undefined keys are
+// recorded in source files, which are not loaded.
+func (s Scope) covers(e BaselineEntry) bool {
+	return s.Rules[e.Rule] && (e.Rule == "undefined-key" || s.Files[e.File])
+}
+
+// Prune removes the entries within scope that are no longer among issues,
+// because they were fixed, and returns how many were removed
+func (b *Baseline) Prune(issues []Issue, scope Scope) int {
+	current := make(map[BaselineEntry]bool)
+	for _, issue := range issues {
+		e := entryOf(issue)
+		current[e] = true
+		current[e.anyLocale()] = true
+	}
+
+	kept := b.Issues[:0]
+	for _, e := range b.Issues {
+		if current[e] || !scope.covers(e) {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(b.Issues) - len(kept)
+	b.Issues = kept
+	return removed
+}
+
+func (b *Baseline) sort() {
+	sort.Slice(b.Issues, func(i, j int) bool {
+		x, y := b.Issues[i], b.Issues[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		if x.Locale != y.Locale {
+			return x.Locale < y.Locale
+		}
+		if x.Key != y.Key {
+			return x.Key < y.Key
+		}
+		return x.Rule < y.Rule
+	})
+}
+
+// filterBaseline removes the issues recorded in the baseline from results and
+// usage, in place
+func filterBaseline(results map[string]CheckResult, usage *UsageResult, b *Baseline) {
+	known := make(map[BaselineEntry]bool)
+	for _, e := range b.Issues {
+		known[e] = true
+	}
+	isNew := func(rule string, file *types.I18nFile, key string) bool {
+		e := BaselineEntry{Rule: rule, File: file.Path, Locale: file.Locale, Key: key}
+		return !known[e] && !known[e.anyLocale()]
+	}
+	filterKeys := func(rule string, file *types.I18nFile, keys []string) []string {
+		var out []string
+		for _, k := range keys {
+			if isNew(rule, file, k) {
+				out = append(out, k)
+			}
+		}
+		return out
+	}
+
+	for id, res := range results {
+		file := res.File
+		res.MissingKeys = filterKeys("missing-key", file, res.MissingKeys)
+		res.EmptyKeys = filterKeys("empty-value", file, res.EmptyKeys)
+		res.FuzzyKeys = filterKeys("fuzzy-translation", file, res.FuzzyKeys)
+		res.OrphanKeys = filterKeys("orphan-key", file, res.OrphanKeys)
+		res.UntranslatedKeys = filterKeys("untranslated", file, res.UntranslatedKeys)
+
+		var placeholders []PlaceholderIssue
+		for _, issue := range res.PlaceholderIssues {
+			if isNew("placeholder-mismatch", file, issue.Key) {
+				placeholders = append(placeholders, issue)
+			}
+		}
+		res.PlaceholderIssues = placeholders
+
+		var icuIssues []ICUIssue
+		for _, issue := range res.ICUIssues {
+			rule := "plural-categories"
+			if issue.Invalid {
+				rule = "icu-syntax"
+			}
+			if isNew(rule, file, issue.Key) {
+				icuIssues = append(icuIssues, issue)
+			}
+		}
+		res.ICUIssues = icuIssues
+
+		var typeIssues []TypeIssue
+		for _, issue := range res.TypeIssues {
+			if isNew("type-mismatch", file, issue.Key) {
+				typeIssues = append(typeIssues, issue)
+			}
+		}
+		res.TypeIssues = typeIssues
+
+		results[id] = res
+	}
+
+	if usage == nil {
+		return
+	}
+
+	// Unused keys are recorded per file defining them
+	var unused []string
+	for _, k := range usage.UnusedKeys {
+		baselined := false
+		for _, e := range b.Issues {
+			if e.Rule == "unused-key" && e.Key == k {
+				baselined = true
+				break
+			}
+		}
+		if !baselined {
+			unused = append(unused, k)
+		}
+	}
+	usage.UnusedKeys = unused
+
+	var undefined []Reference
+	for _, ref := range usage.UndefinedKeys {
+		if e := (BaselineEntry{Rule: "undefined-key", File: ref.File, Key: ref.Key}); !known[e] {
+			undefined = append(undefined, ref)
+		}
+	}
+	usage.UndefinedKeys = undefined
+}
@@ -0,0 +1,176 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestBaselineSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	issues := reportFixture(t)
+
+	baseline := NewBaseline(path, append(issues, issues[0]))
+	if len(baseline.Issues) != len(issues) {
+		t.Errorf("NewBaseline() recorded %d issues, want %d", len(baseline.Issues), len(issues))
+	}
+	if err := baseline.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatalf("LoadBaseline() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Issues, baseline.Issues) {
+		t.Errorf("LoadBaseline() issues = %+v, want %+v", loaded.Issues, baseline.Issues)
+	}
+	for _, issue := range issues {
+		if !loaded.Contains(issue) {
+			t.Errorf("Contains(%s %s) = false, want true", issue.Rule, issue.Key)
+		}
+	}
+
+	// Positions and messages do not matter
+	moved := issues[2]
+	moved.Line, moved.Message = 42, "changed"
+	if !loaded.Contains(moved) {
+		t.Errorf("Contains() of a moved issue = false, want true")
+	}
+
+	if _, err := LoadBaseline(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("LoadBaseline() of a missing file error = nil")
+	}
+	os.WriteFile(path, []byte(`{"version": 2, "issues": []}`), 0644)
+	if _, err := LoadBaseline(path); err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("LoadBaseline() error = %v, want unsupported version", err)
+	}
+}
+
+func TestBaselinePrune(t *testing.T) {
+	issues := reportFixture(t)
+	baseline := NewBaseline("", issues)
+
+	scope := scopeOf(issues)
+	if pruned := baseline.Prune(issues, scope); pruned != 0 {
+		t.Errorf("Prune() with no fixes = %d, want 0", pruned)
+	}
+	if pruned := baseline.Prune(issues[2:], scope); pruned != 2 {
+		t.Errorf("Prune() = %d, want 2", pruned)
+	}
+	if len(baseline.Issues) != len(issues)-2 || baseline.Contains(issues[0]) || !baseline.Contains(issues[2]) {
+		t.Errorf("Prune() left %+v", baseline.Issues)
+	}
+}
+
+func TestBaselinePruneScope(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"a": "Same", "b": "B"}`},
+		{Path: "fr.json", Locale: "fr", Data: `{"a": "Same", "b": "", "c": "C"}`},
+	}
+	opts := Options{Separator: ":", SourceLocale: "en"}
+	results, err := CheckWithOptions(files, opts)
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+	baseline := NewBaseline("", Issues(results, nil, ":"))
+	baseline.Issues = append(baseline.Issues, BaselineEntry{Rule: "missing-key", File: "de.json", Key: "a"})
+	recorded := len(baseline.Issues)
+
+	// Without a source locale, untranslated and orphan keys are not checked
+	opts.SourceLocale = ""
+	results, err = CheckWithOptions(files, opts)
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+	if pruned := baseline.Prune(Issues(results, nil, ":"), ScopeOf(files, opts)); pruned != 0 {
+		t.Errorf("Prune() = %d, want 0: %+v", pruned, baseline.Issues)
+	}
+	for _, issue := range []Issue{
+		{Rule: "untranslated", File: "fr.json", Locale: "fr", Key: "a"},
+		{Rule: "orphan-key", File: "fr.json", Locale: "fr", Key: "c"},
+	} {
+		if !baseline.Contains(issue) {
+			t.Errorf("Prune() dropped the %s entry", issue.Rule)
+		}
+	}
+
+	// Fixed issues of checked rules and loaded files are pruned
+	files[1].Data = `{"a": "Same", "b": "B", "c": "C"}`
+	results, err = CheckWithOptions(files, opts)
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+	if pruned := baseline.Prune(Issues(results, nil, ":"), ScopeOf(files, opts)); pruned != 1 || len(baseline.Issues) != recorded-1 {
+		t.Errorf("Prune() = %d, left %+v", pruned, baseline.Issues)
+	}
+}
+
+func TestFilterBaseline(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"a": "A", "b": "B", "c": "{n, plural, one {x} other {y}}"}`},
+		{Path: "fr.json", Locale: "fr", Data: `{"a": "", "c": "{n, plural, one {x}"}`},
+	}
+	results, err := Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	usage := &UsageResult{
+		UnusedKeys:    []string{"a"},
+		UndefinedKeys: []Reference{{Key: "d", File: "app.js", Line: 1}},
+	}
+	baseline := NewBaseline("", Issues(results, usage, ":"))
+
+	// A new missing key and a new undefined key in a different file
+	files[0].Data = `{"a": "A", "b": "B", "c": "{n, plural, one {x} other {y}}", "e": "E"}`
+	results, err = Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	usage.UndefinedKeys = append(usage.UndefinedKeys, Reference{Key: "d", File: "other.js", Line: 3})
+
+	filterBaseline(results, usage, baseline)
+
+	var got []string
+	for _, issue := range Issues(results, usage, ":") {
+		got = append(got, issue.Rule+" "+issue.File+" "+issue.Key)
+	}
+	want := []string{"missing-key fr.json e", "undefined-key other.js d"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("issues after filterBaseline() = %q, want %q", got, want)
+	}
+}
+
+func TestBaselineLocales(t *testing.T) {
+	de := Issue{Rule: "missing-key", File: "Localizable.xcstrings", Locale: "de", Key: "Bye"}
+	fr := de
+	fr.Locale = "fr"
+
+	baseline := NewBaseline("", []Issue{de})
+	if !baseline.Contains(de) || baseline.Contains(fr) {
+		t.Errorf("Contains() should only match the recorded locale")
+	}
+
+	// Entries recorded without a locale match every locale of the file
+	baseline.Issues = []BaselineEntry{{Rule: "missing-key", File: "Localizable.xcstrings", Key: "Bye"}}
+	if !baseline.Contains(de) || !baseline.Contains(fr) {
+		t.Errorf("Contains() of an entry without locale should match every locale")
+	}
+	if pruned := baseline.Prune([]Issue{fr}, scopeOf([]Issue{fr})); pruned != 0 {
+		t.Errorf("Prune() = %d, want 0", pruned)
+	}
+}
+
+// scopeOf returns a scope covering the rules and files of issues
+func scopeOf(issues []Issue) Scope {
+	s := Scope{Rules: make(map[string]bool), Files: make(map[string]bool)}
+	for _, issue := range issues {
+		s.Rules[issue.Rule] = true
+		s.Files[issue.File] = true
+	}
+	return s
+}
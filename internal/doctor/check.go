@@ -2,10 +2,15 @@ package doctor
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"strings"
 
 	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/icu"
 	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
 )
 
 // CheckResult holds the result of a check for a single file/locale
@@ -13,15 +18,89 @@ type CheckResult struct {
 	File        *types.I18nFile
 	MissingKeys []string
 	EmptyKeys   []string
+	FuzzyKeys   []string // Translations flagged as needing review (gettext "fuzzy")
+
+	OrphanKeys       []string // Keys the source locale does not have
+	UntranslatedKeys []string // Values identical to the source locale
+
+	PlaceholderIssues []PlaceholderIssue
+	ICUIssues         []ICUIssue
+	TypeIssues        []TypeIssue
+}
+
+// PlaceholderIssue reports a value whose placeholders differ from the expected set
+type PlaceholderIssue struct {
+	Key     string
+	Missing []string // Expected placeholders the value does not use
+	Extra   []string // Placeholders the value uses but should not
+}
+
+func (p PlaceholderIssue) String() string {
+	var parts []string
+	for _, token := range p.Missing {
+		parts = append(parts, "missing "+token)
+	}
+	for _, token := range p.Extra {
+		parts = append(parts, "unexpected "+token)
+	}
+	return flatten.UnescapeKey(p.Key) + ": " + strings.Join(parts, ", ")
+}
+
+// ICUIssue reports an invalid ICU message or one lacking plural categories
+type ICUIssue struct {
+	Key     string
+	Problem string
+	Invalid bool // The message does not parse
+}
+
+func (i ICUIssue) String() string {
+	return flatten.UnescapeKey(i.Key) + ": " + i.Problem
+}
+
+// Options configures the doctor checks
+type Options struct {
+	Separator    string
+	SourceLocale string       // Locale translations are compared against, empty to skip comparisons
+	Placeholders []string     // Placeholder syntaxes to compare, DefaultPlaceholderSyntaxes if empty
+	Usage        *UsageResult // Result of CheckUsage to include in the report, if any
+	Format       string       // Report format, one of ReportFormats, or "text"/empty for the text report
+	Baseline     *Baseline    // Known issues left out of the report, if any
+
+	// Values a translation may share with the source locale, like brand names
+	AllowIdentical []string
 }
 
 // Run executes the doctor check on the provided files and prints the report
 // Returns true if issues were found, false otherwise
-func Run(files []*types.I18nFile, simple bool, separator string) (bool, error) {
-	results, err := Check(files, separator)
+func Run(files []*types.I18nFile, simple bool, opts Options) (bool, error) {
+	results, err := CheckWithOptions(files, opts)
 	if err != nil {
 		return false, err
 	}
+	usage := opts.Usage
+
+	if opts.Baseline != nil {
+		// Fixed issues are dropped from the baseline, so they cannot come back unnoticed
+		if pruned := opts.Baseline.Prune(Issues(results, usage, opts.Separator), ScopeOf(files, opts)); pruned > 0 {
+			if err := opts.Baseline.Save(); err != nil {
+				return false, err
+			}
+			fmt.Fprintf(os.Stderr, "Removed %d fixed issues from %s\n", pruned, opts.Baseline.Path())
+		}
+		if usage != nil {
+			filtered := *usage
+			usage = &filtered
+		}
+		filterBaseline(results, usage, opts.Baseline)
+	}
+
+	if opts.Format != "" && opts.Format != "text" {
+		issues := Issues(results, usage, opts.Separator)
+		if err := WriteReport(os.Stdout, opts.Format, issues); err != nil {
+			return false, err
+		}
+		return len(issues) > 0, nil
+	}
 
 	if simple {
 		// Collect unique keys
@@ -33,6 +112,32 @@ func Run(files []*types.I18nFile, simple bool, separator string) (bool, error) {
 			for _, k := range res.EmptyKeys {
 				keySet[k] = true
 			}
+			for _, k := range res.FuzzyKeys {
+				keySet[k] = true
+			}
+			for _, k := range res.OrphanKeys {
+				keySet[k] = true
+			}
+			for _, k := range res.UntranslatedKeys {
+				keySet[k] = true
+			}
+			for _, issue := range res.PlaceholderIssues {
+				keySet[issue.Key] = true
+			}
+			for _, issue := range res.ICUIssues {
+				keySet[issue.Key] = true
+			}
+			for _, issue := range res.TypeIssues {
+				keySet[issue.Key] = true
+			}
+		}
+		if usage != nil {
+			for _, k := range usage.UnusedKeys {
+				keySet[k] = true
+			}
+			for _, ref := range usage.UndefinedKeys {
+				keySet[ref.Key] = true
+			}
 		}
 
 		if len(keySet) == 0 {
@@ -47,43 +152,108 @@ func Run(files []*types.I18nFile, simple bool, separator string) (bool, error) {
 		sort.Strings(keys)
 		// Print
 		for _, k := range keys {
-			fmt.Println(k)
+			fmt.Println(flatten.UnescapeKey(k))
 		}
 		return true, nil
 	}
 
 	hasIssues := false
 
-	// Sort keys (file paths) for consistent output
-	var paths []string
-	for p := range results {
-		paths = append(paths, p)
+	// Sort keys (file paths and locales) for consistent output
+	var ids []string
+	for id := range results {
+		ids = append(ids, id)
 	}
-	sort.Strings(paths)
+	sort.Strings(ids)
 
-	for _, path := range paths {
-		res := results[path]
-		if len(res.MissingKeys) > 0 || len(res.EmptyKeys) > 0 {
+	for _, id := range ids {
+		res := results[id]
+		if len(res.MissingKeys) > 0 || len(res.EmptyKeys) > 0 || len(res.FuzzyKeys) > 0 || len(res.OrphanKeys) > 0 || len(res.UntranslatedKeys) > 0 || len(res.PlaceholderIssues) > 0 || len(res.ICUIssues) > 0 || len(res.TypeIssues) > 0 {
 			hasIssues = true
 			fmt.Printf("File: %s (Locale: %s, Namespace: %s)\n", res.File.Path, res.File.Locale, res.File.Namespace)
 
 			if len(res.MissingKeys) > 0 {
 				fmt.Println("  Missing Keys:")
 				for _, k := range res.MissingKeys {
-					fmt.Printf("    - %s\n", k)
+					fmt.Printf("    - %s\n", flatten.UnescapeKey(k))
 				}
 			}
 
 			if len(res.EmptyKeys) > 0 {
 				fmt.Println("  Empty Keys:")
 				for _, k := range res.EmptyKeys {
-					fmt.Printf("    - %s\n", k)
+					fmt.Printf("    - %s\n", flatten.UnescapeKey(k))
+				}
+			}
+
+			if len(res.FuzzyKeys) > 0 {
+				fmt.Println("  Fuzzy Keys:")
+				for _, k := range res.FuzzyKeys {
+					fmt.Printf("    - %s\n", flatten.UnescapeKey(k))
+				}
+			}
+
+			if len(res.OrphanKeys) > 0 {
+				fmt.Printf("  Orphan Keys (not in source locale %s):\n", opts.SourceLocale)
+				for _, k := range res.OrphanKeys {
+					fmt.Printf("    - %s\n", flatten.UnescapeKey(k))
+				}
+			}
+
+			if len(res.UntranslatedKeys) > 0 {
+				fmt.Printf("  Untranslated Keys (same as source locale %s):\n", opts.SourceLocale)
+				for _, k := range res.UntranslatedKeys {
+					fmt.Printf("    - %s\n", flatten.UnescapeKey(k))
+				}
+			}
+
+			if len(res.PlaceholderIssues) > 0 {
+				fmt.Println("  Placeholder Issues:")
+				for _, issue := range res.PlaceholderIssues {
+					fmt.Printf("    - %s\n", issue)
+				}
+			}
+
+			if len(res.ICUIssues) > 0 {
+				fmt.Println("  ICU Issues:")
+				for _, issue := range res.ICUIssues {
+					fmt.Printf("    - %s\n", issue)
+				}
+			}
+
+			if len(res.TypeIssues) > 0 {
+				fmt.Println("  Type Mismatches:")
+				for _, issue := range res.TypeIssues {
+					fmt.Printf("    - %s\n", issue)
 				}
 			}
 			fmt.Println()
 		}
 	}
 
+	if usage != nil {
+		if len(usage.UnusedKeys) > 0 {
+			hasIssues = true
+			fmt.Println("Unused Keys (not referenced in source code):")
+			for _, k := range usage.UnusedKeys {
+				fmt.Printf("  - %s\n", flatten.UnescapeKey(k))
+			}
+			fmt.Println()
+		}
+		if len(usage.UndefinedKeys) > 0 {
+			hasIssues = true
+			fmt.Println("Undefined Keys (used in source code but missing from every locale):")
+			for _, ref := range usage.UndefinedKeys {
+				fmt.Printf("  - %s\n", ref)
+			}
+			fmt.Println()
+		}
+	}
+
+	if !hasIssues && opts.Baseline != nil {
+		fmt.Printf("No new issues found (%d known issues in %s).\n", len(opts.Baseline.Issues), opts.Baseline.Path())
+		return false, nil
+	}
 	if !hasIssues {
 		fmt.Println("No issues found! All keys are present and non-empty.")
 		return false, nil
@@ -94,11 +264,27 @@ func Run(files []*types.I18nFile, simple bool, separator string) (bool, error) {
 
 // Check performs the analysis and returns results
 func Check(files []*types.I18nFile, separator string) (map[string]CheckResult, error) {
+	return CheckWithOptions(files, Options{Separator: separator})
+}
+
+// CheckWithOptions performs the analysis configured by opts and returns results
+// by i18n.ViewID of each file
+func CheckWithOptions(files []*types.I18nFile, opts Options) (map[string]CheckResult, error) {
 	results := make(map[string]CheckResult)
+	separator := opts.Separator
+	syntaxes := opts.Placeholders
+	if len(syntaxes) == 0 {
+		syntaxes = DefaultPlaceholderSyntaxes
+	}
+	checkICU := false
+	for _, s := range syntaxes {
+		checkICU = checkICU || s == "icu"
+	}
 
 	// Group files by Namespace
 	// Map: Namespace -> Locale -> File
 	groups := make(map[string]map[string]*types.I18nFile)
+	sourceFound := false
 
 	for _, file := range files {
 		ns := file.Namespace
@@ -106,10 +292,14 @@ func Check(files []*types.I18nFile, separator string) (map[string]CheckResult, e
 			groups[ns] = make(map[string]*types.I18nFile)
 		}
 		groups[ns][file.Locale] = file
+		sourceFound = sourceFound || file.Locale == opts.SourceLocale
+	}
+	if opts.SourceLocale != "" && !sourceFound {
+		return nil, fmt.Errorf("source locale %s not found in the loaded files", opts.SourceLocale)
 	}
 
 	// Iterate over each namespace
-	for _, localeFiles := range groups {
+	for ns, localeFiles := range groups {
 		// 1. Flatten all files in this namespace and collect ALL keys
 		allKeys := make(map[string]bool)
 		fileFlats := make(map[string]map[string]string) // Locale -> FlatMap
@@ -131,14 +321,42 @@ func Check(files []*types.I18nFile, separator string) (map[string]CheckResult, e
 		}
 		sort.Strings(sortedKeys)
 
+		declared := declaredPlaceholders(localeFiles, opts.SourceLocale)
+		sourceFile := localeFiles[opts.SourceLocale]
+
+		// With a source locale, only its keys are expected in the other locales
+		expectedKeys := sortedKeys
+		if sourceFile != nil {
+			expectedKeys = nil
+			for _, k := range sortedKeys {
+				if _, ok := fileFlats[sourceFile.Locale][k]; ok {
+					expectedKeys = append(expectedKeys, k)
+				}
+			}
+		}
+
+		// Compare value types and array lengths across locales
+		namespacePrefix := ""
+		if ns != "" {
+			namespacePrefix = ns + separator
+		}
+		typeIssues, err := checkTypes(localeFiles, opts.SourceLocale, namespacePrefix)
+		if err != nil {
+			return nil, err
+		}
+
 		// 2. Check each locale against allKeys
 		for locale, file := range localeFiles {
+			prefix := ""
+			if file.Namespace != "" {
+				prefix = file.Namespace + separator
+			}
 			flat := fileFlats[locale]
 			var missing []string
 			var empty []string
 
 			// Check missing
-			for _, k := range sortedKeys {
+			for _, k := range expectedKeys {
 				if _, exists := flat[k]; !exists {
 					missing = append(missing, k)
 				}
@@ -151,13 +369,145 @@ func Check(files []*types.I18nFile, separator string) (map[string]CheckResult, e
 				}
 			}
 
-			results[file.Path] = CheckResult{
-				File:        file,
-				MissingKeys: missing,
-				EmptyKeys:   empty,
+			// Check fuzzy
+			var fuzzy []string
+			for _, k := range i18n.FuzzyKeys(file) {
+				fuzzy = append(fuzzy, prefix+flatten.EscapeKey(k))
+			}
+
+			// Check keys and values against the source locale
+			var orphans, untranslated []string
+			if sourceFile != nil && file != sourceFile {
+				sourceFlat := fileFlats[sourceFile.Locale]
+				for _, k := range sortedKeys {
+					target, inTarget := flat[k]
+					source, inSource := sourceFlat[k]
+					switch {
+					case !inTarget:
+					case !inSource:
+						orphans = append(orphans, k)
+					case target == source && isUntranslated(target, opts.AllowIdentical):
+						untranslated = append(untranslated, k)
+					}
+				}
+			}
+
+			// Check placeholders against the declared ones, or else the source locale
+			placeholderIssues := checkDeclaredPlaceholders(file, declared, prefix)
+			if sourceFile != nil && file != sourceFile {
+				sourceFlat := fileFlats[sourceFile.Locale]
+				for _, k := range sortedKeys {
+					source, inSource := sourceFlat[k]
+					target, inTarget := flat[k]
+					if !inSource || !inTarget || declared[flatten.UnescapeKey(strings.TrimPrefix(k, prefix))] != nil {
+						continue
+					}
+					if issue := comparePlaceholders(k, source, target, syntaxes); issue != nil {
+						placeholderIssues = append(placeholderIssues, *issue)
+					}
+				}
+				sort.SliceStable(placeholderIssues, func(i, j int) bool {
+					return placeholderIssues[i].Key < placeholderIssues[j].Key
+				})
+			}
+
+			// Check ICU syntax and plural categories
+			var icuIssues []ICUIssue
+			if checkICU {
+				for _, k := range sortedKeys {
+					if v, ok := flat[k]; ok {
+						icuIssues = append(icuIssues, checkICUMessage(k, v, file.Locale)...)
+					}
+				}
+			}
+
+			results[i18n.ViewID(file)] = CheckResult{
+				File:              file,
+				MissingKeys:       missing,
+				EmptyKeys:         empty,
+				FuzzyKeys:         fuzzy,
+				OrphanKeys:        orphans,
+				UntranslatedKeys:  untranslated,
+				PlaceholderIssues: placeholderIssues,
+				ICUIssues:         icuIssues,
+				TypeIssues:        typeIssues[i18n.ViewID(file)],
 			}
 		}
 	}
 
 	return results, nil
 }
+
+// declaredPlaceholders collects the placeholders declared in file metadata
+// (the ARB template) by key. The template is the file of the source locale;
+// without one, the declarations of all locales are merged, visiting locales in
+// order so the result does not depend on map iteration.
+func declaredPlaceholders(localeFiles map[string]*types.I18nFile, sourceLocale string) map[string][]string {
+	locales := make([]string, 0, len(localeFiles))
+	if _, ok := localeFiles[sourceLocale]; ok {
+		locales = append(locales, sourceLocale)
+	} else {
+		for locale := range localeFiles {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+	}
+
+	declared := make(map[string][]string)
+	for _, locale := range locales {
+		file := localeFiles[locale]
+		gjson.Parse(file.Data).ForEach(func(k, _ gjson.Result) bool {
+			if _, ok := declared[k.String()]; ok {
+				return true
+			}
+			if names, ok := i18n.DeclaredPlaceholders(file, k.String()); ok {
+				declared[k.String()] = names
+			}
+			return true
+		})
+	}
+	return declared
+}
+
+// checkDeclaredPlaceholders compares the ICU arguments of each message with
+// the placeholders declared for it
+func checkDeclaredPlaceholders(file *types.I18nFile, declared map[string][]string, prefix string) []PlaceholderIssue {
+	keys := make([]string, 0, len(declared))
+	for k := range declared {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var issues []PlaceholderIssue
+	for _, key := range keys {
+		value := gjson.Get(file.Data, gjson.Escape(key))
+		if value.Type != gjson.String || value.String() == "" {
+			continue
+		}
+		msg, err := icu.Parse(value.String())
+		if err != nil {
+			continue
+		}
+
+		used := make(map[string]bool)
+		for _, name := range msg.Arguments() {
+			used[name] = true
+		}
+		issue := PlaceholderIssue{Key: prefix + flatten.EscapeKey(key)}
+		for _, name := range declared[key] {
+			if !used[name] {
+				issue.Missing = append(issue.Missing, "{"+name+"}")
+			}
+			delete(used, name)
+		}
+		for _, name := range msg.Arguments() {
+			if used[name] {
+				issue.Extra = append(issue.Extra, "{"+name+"}")
+			}
+		}
+		if len(issue.Missing) > 0 || len(issue.Extra) > 0 {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -1,9 +1,12 @@
 package doctor
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/kikyous/i18nedt/internal/i18n"
 	"github.com/kikyous/i18nedt/pkg/types"
 )
 
@@ -106,3 +109,201 @@ func TestCheck_Namespaces(t *testing.T) {
 		t.Errorf("ns2_en.json should have no missing keys, got %v", en2Res.MissingKeys)
 	}
 }
+
+// fileWriter returns a function writing a file to a temporary directory and
+// loading it in its format
+func fileWriter(t *testing.T) func(name, content string) *types.I18nFile {
+	t.Helper()
+	dir := t.TempDir()
+	return func(name, content string) *types.I18nFile {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		file, err := i18n.LoadFile(path, "")
+		if err != nil {
+			t.Fatalf("LoadFile failed: %v", err)
+		}
+		return file
+	}
+}
+
+func TestCheck_GettextFuzzy(t *testing.T) {
+	write := fileWriter(t)
+
+	de := write("de.po", "#, fuzzy\nmsgid \"Hello.\"\nmsgstr \"Hallo.\"\n\nmsgid \"Bye\"\nmsgstr \"\"\n")
+	fr := write("fr.po", "msgid \"Hello.\"\nmsgstr \"Bonjour.\"\n\nmsgid \"Bye\"\nmsgstr \"Salut\"\n")
+
+	results, err := Check([]*types.I18nFile{de, fr}, ":")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	deRes := results[de.Path]
+	if !reflect.DeepEqual(deRes.FuzzyKeys, []string{`Hello\.`}) {
+		t.Errorf("de.po should have fuzzy 'Hello\\.', got %v", deRes.FuzzyKeys)
+	}
+	if !reflect.DeepEqual(deRes.EmptyKeys, []string{"Bye"}) {
+		t.Errorf("de.po should have untranslated 'Bye', got %v", deRes.EmptyKeys)
+	}
+	if frRes := results[fr.Path]; len(frRes.FuzzyKeys) > 0 || len(frRes.EmptyKeys) > 0 {
+		t.Errorf("fr.po should have no issues, got %+v", frRes)
+	}
+}
+
+func TestCheck_ARBPlaceholders(t *testing.T) {
+	write := fileWriter(t)
+
+	en := write("app_en.arb", `{
+  "@@locale": "en",
+  "greeting": "Hello {name}, you have {count, plural, one {# message} other {# messages}}",
+  "@greeting": {"description": "Home", "placeholders": {"name": {}, "count": {}}}
+}`)
+	de := write("app_de.arb", `{
+  "@@locale": "de",
+  "greeting": "Hallo {nmae}, du hast {count, plural, one {# Nachricht} other {# Nachrichten}}"
+}`)
+
+	results, err := Check([]*types.I18nFile{en, de}, ":")
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	// Metadata entries are not messages
+	if res := results[en.Path]; len(res.MissingKeys) > 0 || len(res.PlaceholderIssues) > 0 {
+		t.Errorf("app_en.arb should have no issues, got %+v", res)
+	}
+
+	deRes := results[de.Path]
+	if len(deRes.MissingKeys) > 0 {
+		t.Errorf("app_de.arb should not miss metadata keys, got %v", deRes.MissingKeys)
+	}
+	want := []PlaceholderIssue{{Key: "greeting", Missing: []string{"{name}"}, Extra: []string{"{nmae}"}}}
+	if !reflect.DeepEqual(deRes.PlaceholderIssues, want) {
+		t.Errorf("app_de.arb placeholder issues = %+v, want %+v", deRes.PlaceholderIssues, want)
+	}
+	if got := want[0].String(); got != "greeting: missing {name}, unexpected {nmae}" {
+		t.Errorf("PlaceholderIssue.String() = %q", got)
+	}
+}
+
+func TestCheck_ARBTemplatePlaceholders(t *testing.T) {
+	write := fileWriter(t)
+
+	// The German file sorts first and carries stale declarations
+	de := write("app_de.arb", `{
+  "@@locale": "de",
+  "greeting": "Hallo {user}",
+  "@greeting": {"placeholders": {"user": {}}}
+}`)
+	en := write("app_en.arb", `{
+  "@@locale": "en",
+  "greeting": "Hello {name}",
+  "@greeting": {"placeholders": {"name": {}}}
+}`)
+
+	results, err := CheckWithOptions([]*types.I18nFile{de, en}, Options{Separator: ":", SourceLocale: "en", Placeholders: []string{"icu"}})
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+	if res := results[en.Path]; len(res.PlaceholderIssues) > 0 {
+		t.Errorf("app_en.arb placeholder issues = %+v, want none", res.PlaceholderIssues)
+	}
+	want := PlaceholderIssue{Key: "greeting", Missing: []string{"{name}"}, Extra: []string{"{user}"}}
+	if got := results[de.Path].PlaceholderIssues; !reflect.DeepEqual(got, []PlaceholderIssue{want}) {
+		t.Errorf("app_de.arb placeholder issues = %+v, want %+v", got, []PlaceholderIssue{want})
+	}
+}
+
+func TestCheckWithOptions_SourceLocale(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"title": "Settings", "brand": "GitHub", "count": "{{count}}", "hint": "Save {{name}}", "help": "Help"}`},
+		{Path: "fr.json", Locale: "fr", Data: `{"title": "Settings", "brand": "GitHub", "count": "{{count}}", "hint": "Save {{name}}"}`},
+		{Path: "zh-TW.json", Locale: "zh-TW", Data: `{"title": "設定", "titel": "設定"}`},
+	}
+
+	results, err := CheckWithOptions(files, Options{Separator: ":", SourceLocale: "en", AllowIdentical: []string{"GitHub"}})
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+
+	tests := []struct {
+		path         string
+		missing      []string
+		orphans      []string
+		untranslated []string
+	}{
+		{"en.json", nil, nil, nil},
+		{"fr.json", []string{"help"}, nil, []string{"hint", "title"}},
+		{"zh-TW.json", []string{"brand", "count", "help", "hint"}, []string{"titel"}, nil},
+	}
+	for _, tt := range tests {
+		res := results[tt.path]
+		if !reflect.DeepEqual(res.MissingKeys, tt.missing) {
+			t.Errorf("%s MissingKeys = %v, want %v", tt.path, res.MissingKeys, tt.missing)
+		}
+		if !reflect.DeepEqual(res.OrphanKeys, tt.orphans) {
+			t.Errorf("%s OrphanKeys = %v, want %v", tt.path, res.OrphanKeys, tt.orphans)
+		}
+		if !reflect.DeepEqual(res.UntranslatedKeys, tt.untranslated) {
+			t.Errorf("%s UntranslatedKeys = %v, want %v", tt.path, res.UntranslatedKeys, tt.untranslated)
+		}
+	}
+
+	// Without a source locale all locales are peers
+	results, _ = Check(files, ":")
+	if got := results["en.json"].MissingKeys; !reflect.DeepEqual(got, []string{"titel"}) {
+		t.Errorf("Check() en.json MissingKeys = %v, want [titel]", got)
+	}
+	if got := results["fr.json"].UntranslatedKeys; got != nil {
+		t.Errorf("Check() fr.json UntranslatedKeys = %v, want none", got)
+	}
+}
+
+func TestCheck_MultiLocaleFile(t *testing.T) {
+	// The locales of a catalog are views sharing one path
+	path := filepath.Join(t.TempDir(), "Localizable.xcstrings")
+	catalog := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "Bye" : {
+      "localizations" : {
+        "en" : { "stringUnit" : { "state" : "translated", "value" : "Bye" } }
+      }
+    },
+    "Hello" : {
+      "localizations" : {
+        "de" : { "stringUnit" : { "state" : "translated", "value" : "" } },
+        "en" : { "stringUnit" : { "state" : "translated", "value" : "Hello" } }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+	if err := os.WriteFile(path, []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := i18n.LoadAllFiles([]types.FileSource{{Path: path}})
+	if err != nil {
+		t.Fatalf("LoadAllFiles() error = %v", err)
+	}
+
+	results, err := Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Check() returned %d results, want one per locale", len(results))
+	}
+
+	var got []string
+	for _, issue := range Issues(results, nil, ":") {
+		got = append(got, issue.Locale+" "+issue.Rule+" "+issue.Key)
+	}
+	want := []string{"de missing-key Bye", "de empty-value Hello"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Issues() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// FixTempFile creates a temporary file with the missing and empty keys found
+// in results, holding only the locales that lack a value. The value of the
+// source locale, or without one the values of the other locales, is shown as
+// context. It returns nil if there is nothing to fix.
+func FixTempFile(files []*types.I18nFile, results map[string]CheckResult, sourceLocale, separator string) (*types.TempFile, error) {
+	affected := make(map[string]map[string]bool) // Key -> locales lacking a value
+	for _, res := range results {
+		for _, keys := range [][]string{res.MissingKeys, res.EmptyKeys} {
+			for _, k := range keys {
+				if affected[k] == nil {
+					affected[k] = make(map[string]bool)
+				}
+				affected[k][res.File.Locale] = true
+			}
+		}
+	}
+	if len(affected) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(affected))
+	for k := range affected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	temp, err := editor.CreateTempFile(files, keys, separator)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		values := temp.Content[key]
+		var context []string
+		if desc := temp.Context[key]; desc != "" {
+			context = append(context, desc)
+		}
+
+		locales := make([]string, 0, len(values))
+		for locale := range values {
+			locales = append(locales, locale)
+		}
+		sort.Strings(locales)
+
+		for _, locale := range locales {
+			if affected[key][locale] {
+				continue
+			}
+			if v := values[locale]; v.Value != "" && (sourceLocale == "" || locale == sourceLocale) {
+				context = append(context, locale+": "+contextValue(v))
+			}
+			delete(values, locale)
+		}
+		temp.Context[key] = strings.Join(context, "\n")
+	}
+
+	return temp, nil
+}
+
+// contextValue renders a value on a single comment line
+func contextValue(v *types.Value) string {
+	if v.Type == types.ValueTypeJSON {
+		var compact bytes.Buffer
+		if json.Compact(&compact, []byte(v.Value)) == nil {
+			return compact.String()
+		}
+	}
+	return strings.ReplaceAll(v.Value, "\n", `\n`)
+}
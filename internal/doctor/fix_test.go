@@ -0,0 +1,72 @@
+package doctor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestFixTempFile(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"title": "Home", "menu": {"open": "Open", "close": "Close"}, "tags": ["a"]}`},
+		{Path: "de.json", Locale: "de", Data: `{"title": "", "menu": {"open": "Öffnen"}, "tags": ["a"]}`},
+		{Path: "fr.json", Locale: "fr", Data: `{"title": "Accueil", "menu": {"open": "Ouvrir"}, "tags": ["a"]}`},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		content map[string][]string // Key -> locales to edit
+		context map[string]string
+	}{
+		{
+			name:    "source locale",
+			source:  "en",
+			content: map[string][]string{"menu.close": {"de", "fr"}, "title": {"de"}},
+			context: map[string]string{"menu.close": "en: Close", "title": "en: Home"},
+		},
+		{
+			name:    "peers",
+			content: map[string][]string{"menu.close": {"de", "fr"}, "title": {"de"}},
+			context: map[string]string{"menu.close": "en: Close", "title": "en: Home\nfr: Accueil"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := CheckWithOptions(files, Options{Separator: ":", SourceLocale: tt.source})
+			if err != nil {
+				t.Fatalf("CheckWithOptions() error = %v", err)
+			}
+			temp, err := FixTempFile(files, results, tt.source, ":")
+			if err != nil {
+				t.Fatalf("FixTempFile() error = %v", err)
+			}
+
+			content := make(map[string][]string)
+			for key, values := range temp.Content {
+				for _, locale := range []string{"de", "en", "fr"} {
+					if v, ok := values[locale]; ok {
+						content[key] = append(content[key], locale)
+						if v.Value != "" {
+							t.Errorf("FixTempFile() %s %s = %q, want empty", key, locale, v.Value)
+						}
+					}
+				}
+			}
+			if !reflect.DeepEqual(content, tt.content) {
+				t.Errorf("FixTempFile() locales = %v, want %v", content, tt.content)
+			}
+			if !reflect.DeepEqual(temp.Context, tt.context) {
+				t.Errorf("FixTempFile() context = %q, want %q", temp.Context, tt.context)
+			}
+		})
+	}
+
+	// Nothing to fix
+	results, _ := Check(files[:1], ":")
+	if temp, err := FixTempFile(files[:1], results, "", ":"); temp != nil || err != nil {
+		t.Errorf("FixTempFile() without issues = %v, %v, want nil", temp, err)
+	}
+}
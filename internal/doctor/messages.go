@@ -0,0 +1,43 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/icu"
+)
+
+// icuMessage matches values using typed ICU arguments like {n, plural, ...};
+// plain {name} placeholders are shared with other syntaxes and not enough to
+// treat a value as an ICU message
+var icuMessage = regexp.MustCompile(`(?:^|[^{])\{\s*[^\s{},]+\s*,\s*(?:plural|select|selectordinal|number|date|time|spellout|ordinal|duration)\s*[,}]`)
+
+// checkICUMessage reports syntax errors in an ICU message and plural
+// arguments that lack categories used by locale
+func checkICUMessage(key, valueJSON, locale string) []ICUIssue {
+	var value string
+	if json.Unmarshal([]byte(valueJSON), &value) != nil || !icuMessage.MatchString(value) {
+		return nil
+	}
+
+	msg, err := icu.Parse(value)
+	if err != nil {
+		return []ICUIssue{{Key: key, Problem: "invalid ICU message: " + err.Error(), Invalid: true}}
+	}
+
+	// Locales unknown to CLDR have no plural rules to check
+	missing, err := msg.MissingPluralCategories(locale)
+	if err != nil {
+		return nil
+	}
+	var issues []ICUIssue
+	for _, m := range missing {
+		issues = append(issues, ICUIssue{
+			Key:     key,
+			Problem: fmt.Sprintf("{%s} is missing plural categories: %s", m.Argument, strings.Join(m.Missing, ", ")),
+		})
+	}
+	return issues
+}
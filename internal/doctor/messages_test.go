@@ -0,0 +1,50 @@
+package doctor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestCheck_ICUMessages(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{
+			"items": "{count, plural, one {# item} other {# items}}",
+			"broken": "{count, plural, one {# item}}",
+			"greeting": "Hello {{name}}"
+		}`},
+		{Path: "pl.json", Locale: "pl", Data: `{
+			"items": "{count, plural, one {# produkt} other {# produktu}}",
+			"broken": "{count, plural, one {# produkt} other {# produktu}}",
+			"greeting": "Cześć {{name}}"
+		}`},
+	}
+
+	results, err := Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	wantEn := []ICUIssue{{Key: "broken", Problem: "invalid ICU message: offset 28: plural argument \"count\" is missing the 'other' branch", Invalid: true}}
+	if got := results["en.json"].ICUIssues; !reflect.DeepEqual(got, wantEn) {
+		t.Errorf("en.json ICU issues = %+v, want %+v", got, wantEn)
+	}
+
+	wantPl := []ICUIssue{
+		{Key: "broken", Problem: "{count} is missing plural categories: few, many"},
+		{Key: "items", Problem: "{count} is missing plural categories: few, many"},
+	}
+	if got := results["pl.json"].ICUIssues; !reflect.DeepEqual(got, wantPl) {
+		t.Errorf("pl.json ICU issues = %+v, want %+v", got, wantPl)
+	}
+
+	// ICU checks follow the placeholder syntaxes
+	results, err = CheckWithOptions(files, Options{Separator: ":", Placeholders: []string{"i18next"}})
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+	if got := results["pl.json"].ICUIssues; len(got) != 0 {
+		t.Errorf("ICU issues without the icu syntax = %+v", got)
+	}
+}
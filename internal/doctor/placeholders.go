@@ -0,0 +1,184 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/kikyous/i18nedt/internal/icu"
+)
+
+// DefaultPlaceholderSyntaxes are compared when no syntax is configured
+var DefaultPlaceholderSyntaxes = []string{"i18next", "icu"}
+
+// placeholderSyntaxes extract the interpolation tokens of a value, in the
+// form they are written (e.g. "{{count}}", "{name}", "%1$s")
+var placeholderSyntaxes = map[string]func(string) []string{
+	"i18next": i18nextPlaceholders,
+	"icu":     icuPlaceholders,
+	"printf":  printfPlaceholders,
+	"vue":     vuePlaceholders,
+}
+
+var (
+	i18nextPlaceholder = regexp.MustCompile(`\{\{-?\s*([^\s,{}]+)\s*(?:,[^{}]*)?\}\}|\$t\(([^()]*)\)`)
+	printfPlaceholder  = regexp.MustCompile(`%%|%\(\w+\)[-+0#]*\d*(?:\.\d+)?[diouxXeEfFgGcrs]|%(?:\d+\$)?[-+0#']*(?:\d+|\*)?(?:\.(?:\d+|\*))?(?:hh|h|ll|l|L|q|j|z|t)?[diouxXeEfFgGaAcspn@]`)
+	vuePlaceholder     = regexp.MustCompile(`%?\{\s*(\w+)\s*\}|@(?:\.\w+)?:\(?([\w.\-]+)\)?`)
+)
+
+// PlaceholderSyntaxNames returns the supported placeholder syntaxes
+func PlaceholderSyntaxNames() []string {
+	names := make([]string, 0, len(placeholderSyntaxes))
+	for name := range placeholderSyntaxes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidatePlaceholderSyntaxes checks that all syntaxes are supported
+func ValidatePlaceholderSyntaxes(syntaxes []string) error {
+	for _, s := range syntaxes {
+		if _, ok := placeholderSyntaxes[s]; !ok {
+			return fmt.Errorf("unknown placeholder syntax %q (supported: %s)", s, strings.Join(PlaceholderSyntaxNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// i18nextPlaceholders returns interpolations like {{name}} or {{date, short}}
+// as {{name}}, and nested translations like $t(key)
+func i18nextPlaceholders(s string) []string {
+	var tokens []string
+	for _, m := range i18nextPlaceholder.FindAllStringSubmatch(s, -1) {
+		if m[1] != "" {
+			tokens = append(tokens, "{{"+m[1]+"}}")
+		} else {
+			tokens = append(tokens, m[0])
+		}
+	}
+	return tokens
+}
+
+// icuPlaceholders returns the arguments of an ICU message as {name}. Values
+// that are not valid ICU messages have none.
+func icuPlaceholders(s string) []string {
+	if !strings.Contains(s, "{") {
+		return nil
+	}
+	msg, err := icu.Parse(s)
+	if err != nil {
+		return nil
+	}
+	var tokens []string
+	for _, name := range msg.Arguments() {
+		tokens = append(tokens, "{"+name+"}")
+	}
+	return tokens
+}
+
+// printfPlaceholders returns format verbs like %s, %1$d or %(name)s. Unnumbered
+// verbs are matched by position, so every occurrence counts.
+func printfPlaceholders(s string) []string {
+	var tokens []string
+	for _, m := range printfPlaceholder.FindAllString(s, -1) {
+		if m != "%%" {
+			tokens = append(tokens, m)
+		}
+	}
+	return tokens
+}
+
+// vuePlaceholders returns vue-i18n named and list interpolations as {name}
+// and linked messages as @:key
+func vuePlaceholders(s string) []string {
+	var tokens []string
+	for _, m := range vuePlaceholder.FindAllStringSubmatch(s, -1) {
+		if m[1] != "" {
+			tokens = append(tokens, "{"+m[1]+"}")
+		} else {
+			tokens = append(tokens, "@:"+m[2])
+		}
+	}
+	return tokens
+}
+
+// extractPlaceholders returns the tokens of value in all syntaxes, counting
+// how often each one must appear. Named tokens only need to appear once,
+// positional printf verbs as often as in the source.
+func extractPlaceholders(value string, syntaxes []string) map[string]int {
+	counts := make(map[string]int)
+	for _, syntax := range syntaxes {
+		for _, token := range placeholderSyntaxes[syntax](value) {
+			if syntax == "printf" && !strings.Contains(token, "$") && !strings.HasPrefix(token, "%(") {
+				counts[token]++
+			} else {
+				counts[token] = 1
+			}
+		}
+	}
+	return counts
+}
+
+// comparePlaceholders returns the placeholder differences between the source
+// value and a translation of it, or nil if either is not a non-empty string
+func comparePlaceholders(key, sourceJSON, targetJSON string, syntaxes []string) *PlaceholderIssue {
+	var source, target string
+	if json.Unmarshal([]byte(sourceJSON), &source) != nil || json.Unmarshal([]byte(targetJSON), &target) != nil {
+		return nil
+	}
+	if source == "" || target == "" {
+		return nil
+	}
+
+	want := extractPlaceholders(source, syntaxes)
+	got := extractPlaceholders(target, syntaxes)
+
+	issue := &PlaceholderIssue{Key: key}
+	for _, token := range sortedTokens(want) {
+		for i := got[token]; i < want[token]; i++ {
+			issue.Missing = append(issue.Missing, token)
+		}
+	}
+	for _, token := range sortedTokens(got) {
+		for i := want[token]; i < got[token]; i++ {
+			issue.Extra = append(issue.Extra, token)
+		}
+	}
+	if len(issue.Missing) == 0 && len(issue.Extra) == 0 {
+		return nil
+	}
+	return issue
+}
+
+func sortedTokens(counts map[string]int) []string {
+	tokens := make([]string, 0, len(counts))
+	for token := range counts {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// isUntranslated reports whether a target value identical to its source value
+// is likely a forgotten translation: a string with text besides placeholders
+// that is not in the allowlist
+func isUntranslated(valueJSON string, allowed []string) bool {
+	var value string
+	if json.Unmarshal([]byte(valueJSON), &value) != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if strings.TrimSpace(value) == a {
+			return false
+		}
+	}
+
+	for _, re := range []*regexp.Regexp{i18nextPlaceholder, printfPlaceholder, vuePlaceholder} {
+		value = re.ReplaceAllString(value, "")
+	}
+	return strings.IndexFunc(value, unicode.IsLetter) >= 0
+}
@@ -0,0 +1,93 @@
+package doctor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestPlaceholderSyntaxes(t *testing.T) {
+	tests := []struct {
+		syntax string
+		value  string
+		want   []string
+	}{
+		{"i18next", "Hello {{name}}, you have {{ count }} items on {{date, short}}", []string{"{{name}}", "{{count}}", "{{date}}"}},
+		{"i18next", "Unescaped {{- html}} and $t(common:title)", []string{"{{html}}", "$t(common:title)"}},
+		{"icu", "{count, plural, one {# item for {name}} other {# items}}", []string{"{count}", "{name}"}},
+		{"icu", "Hello {{name}}", nil},
+		{"printf", "%s has %d items, 100%% done", []string{"%s", "%d"}},
+		{"printf", "%2$s before %1$s, %(user)s, %.2f, %@", []string{"%2$s", "%1$s", "%(user)s", "%.2f", "%@"}},
+		{"vue", "Hello {name}, {0} @:common.title %{legacy}", []string{"{name}", "{0}", "@:common.title", "{legacy}"}},
+	}
+
+	for _, tt := range tests {
+		if got := placeholderSyntaxes[tt.syntax](tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s placeholders of %q = %v, want %v", tt.syntax, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestComparePlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		target   string
+		syntaxes []string
+		want     *PlaceholderIssue
+	}{
+		{"same", `"{{count}} items"`, `"{{count}} Artikel"`, DefaultPlaceholderSyntaxes, nil},
+		{"reordered", `"{a} and {b}"`, `"{b} und {a}"`, DefaultPlaceholderSyntaxes, nil},
+		{"misspelled", `"Hi {{name}}"`, `"Hallo {{nmae}}"`, DefaultPlaceholderSyntaxes,
+			&PlaceholderIssue{Key: "k", Missing: []string{"{{name}}"}, Extra: []string{"{{nmae}}"}}},
+		{"dropped printf", `"%s of %s"`, `"%s"`, []string{"printf"},
+			&PlaceholderIssue{Key: "k", Missing: []string{"%s"}}},
+		{"named used twice", `"{name}"`, `"{name} {name}"`, DefaultPlaceholderSyntaxes, nil},
+		{"empty target", `"{name}"`, `""`, DefaultPlaceholderSyntaxes, nil},
+		{"not a string", `["{name}"]`, `[]`, DefaultPlaceholderSyntaxes, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := comparePlaceholders("k", tt.source, tt.target, tt.syntaxes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("comparePlaceholders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckWithOptions_SourcePlaceholders(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: `{"greeting": "Hi {{name}}", "total": "%d items", "extra": "Hello"}`},
+		{Path: "de/common.json", Locale: "de", Namespace: "common", Data: `{"greeting": "Hallo", "total": "%s Artikel", "extra": "Hallo {{name}}"}`},
+	}
+
+	results, err := CheckWithOptions(files, Options{Separator: ":", SourceLocale: "en", Placeholders: []string{"i18next", "printf"}})
+	if err != nil {
+		t.Fatalf("CheckWithOptions() error = %v", err)
+	}
+
+	if issues := results["en/common.json"].PlaceholderIssues; len(issues) > 0 {
+		t.Errorf("source locale should have no placeholder issues, got %v", issues)
+	}
+	want := []PlaceholderIssue{
+		{Key: "common:extra", Extra: []string{"{{name}}"}},
+		{Key: "common:greeting", Missing: []string{"{{name}}"}},
+		{Key: "common:total", Missing: []string{"%d"}, Extra: []string{"%s"}},
+	}
+	if got := results["de/common.json"].PlaceholderIssues; !reflect.DeepEqual(got, want) {
+		t.Errorf("de placeholder issues = %+v, want %+v", got, want)
+	}
+
+	// Without a source locale nothing is compared
+	results, _ = Check(files, ":")
+	if issues := results["de/common.json"].PlaceholderIssues; len(issues) > 0 {
+		t.Errorf("Check() without source locale reported %v", issues)
+	}
+
+	if _, err := CheckWithOptions(files, Options{Separator: ":", SourceLocale: "fr"}); err == nil {
+		t.Error("CheckWithOptions() should fail for an unknown source locale")
+	}
+}
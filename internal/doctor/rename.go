@@ -0,0 +1,153 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// SourceChange is a source file whose key references were rewritten
+type SourceChange struct {
+	Path    string
+	Content string
+	Count   int // Number of references rewritten
+}
+
+// RenameInSources rewrites the references to key from, and the keys below
+// it, into to in the files matching patterns. Only changed files are
+// returned, nothing is written.
+func RenameInSources(patterns, functions []string, separator, from, to string) ([]SourceChange, error) {
+	var changes []SourceChange
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, err := doublestar.FilepathGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no source files match %s", pattern)
+		}
+
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read source file %s: %w", path, err)
+			}
+			if renamed, count := RenameReferences(string(content), functions, separator, from, to); count > 0 {
+				changes = append(changes, SourceChange{Path: path, Content: renamed, Count: count})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// RenameReferences rewrites the string literal keys of translation calls and
+// <Trans i18nKey="..."> elements in content that refer to from or a key
+// below it. A key without a namespace refers to the namespace passed to
+// useTranslation, and keeps it short if to stays in that namespace. It
+// returns the new content and the number of references rewritten.
+func RenameReferences(content string, functions []string, separator, from, to string) (string, int) {
+	if len(functions) == 0 {
+		functions = DefaultFunctions
+	}
+
+	defaultNs := ""
+	if m := useTranslation.FindStringSubmatch(content); m != nil {
+		defaultNs = m[1]
+	}
+
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	consider := func(start, end int, quote byte, ns string) {
+		if start < 0 {
+			return
+		}
+		if ns == "" {
+			ns = defaultNs
+		}
+		if key, ok := renamedKey(content[start:end], ns, separator, from, to); ok && !strings.ContainsAny(key, `\`+string(quote)) {
+			edits = append(edits, edit{start, end, key})
+		}
+	}
+
+	for _, m := range callPattern(functions).FindAllStringSubmatchIndex(content, -1) {
+		consider(m[2], m[3], '\'', "")
+		consider(m[4], m[5], '"', "")
+		consider(m[6], m[7], '`', "")
+	}
+
+	for _, loc := range transTag.FindAllStringIndex(content, -1) {
+		tag := content[loc[0]:loc[1]]
+		m := transKeyAttr.FindStringSubmatchIndex(tag)
+		if m == nil {
+			continue
+		}
+		ns := ""
+		if n := transNsAttr.FindStringSubmatch(tag); n != nil {
+			ns = n[1] + n[2]
+		}
+		for i, quote := range []byte{'"', '\'', '"', '\'', '`'} {
+			if start := m[2+2*i]; start >= 0 {
+				consider(loc[0]+start, loc[0]+m[3+2*i], quote, ns)
+			}
+		}
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		content = content[:e.start] + e.text + content[e.end:]
+	}
+	return content, len(edits)
+}
+
+// renamedKey returns the key written in code after renaming from to to, if
+// written refers to from or a key below it. ns is the namespace keys without
+// one refer to.
+func renamedKey(written, ns, separator, from, to string) (string, bool) {
+	if strings.Contains(written, `\`) {
+		return "", false
+	}
+	fromNs, fromPath := splitNamespace(from, separator)
+	toNs, toPath := splitNamespace(to, separator)
+
+	writtenNs, path := splitNamespace(written, separator)
+	explicit := strings.Contains(written, separator)
+	if !explicit {
+		writtenNs = ns
+	}
+	// Without namespaces, keys are matched whatever namespace the code uses
+	if writtenNs != fromNs && (fromNs != "" || explicit) {
+		return "", false
+	}
+
+	rest, ok := strings.CutPrefix(path, fromPath)
+	if !ok || rest != "" && !strings.HasPrefix(rest, ".") {
+		return "", false
+	}
+	renamed := toPath + rest
+
+	switch {
+	case toNs == "":
+		if explicit && writtenNs != "" || !explicit && fromNs != "" {
+			// The default namespace can't be written in a key
+			return "", false
+		}
+		return renamed, true
+	case !explicit && toNs == writtenNs:
+		return renamed, true
+	}
+	return toNs + separator + renamed, true
+}
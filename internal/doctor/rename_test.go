@@ -0,0 +1,108 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		from, to  string
+		want      string
+		wantCount int
+	}{
+		{
+			name:      "plain keys",
+			content:   `t('home.title'); t("home.title.sub"); t('home.titles'); $t(` + "`home.title`" + `)`,
+			from:      "home.title",
+			to:        "landing.heading",
+			want:      `t('landing.heading'); t("landing.heading.sub"); t('home.titles'); $t(` + "`landing.heading`" + `)`,
+			wantCount: 3,
+		},
+		{
+			name:      "dynamic keys below the renamed key",
+			content:   "t('home.' + id); t(`home.${id}`); t(other)",
+			from:      "home",
+			to:        "landing",
+			want:      "t('landing.' + id); t(`landing.${id}`); t(other)",
+			wantCount: 2,
+		},
+		{
+			name:      "explicit namespace",
+			content:   `t('common:title'); t('auth:title'); t('title')`,
+			from:      "common:title",
+			to:        "home:heading",
+			want:      `t('home:heading'); t('auth:title'); t('title')`,
+			wantCount: 1,
+		},
+		{
+			name:      "namespace from useTranslation",
+			content:   `useTranslation('common'); t('title'); t('nav.back')`,
+			from:      "common:title",
+			to:        "common:heading",
+			want:      `useTranslation('common'); t('heading'); t('nav.back')`,
+			wantCount: 1,
+		},
+		{
+			name:      "moved out of the useTranslation namespace",
+			content:   `useTranslation('common'); t('title')`,
+			from:      "common:title",
+			to:        "home:title",
+			want:      `useTranslation('common'); t('home:title')`,
+			wantCount: 1,
+		},
+		{
+			name:      "trans elements",
+			content:   `<Trans i18nKey="title" ns="common" /> <Trans i18nKey={'common:title'}>x</Trans>`,
+			from:      "common:title",
+			to:        "home:title",
+			want:      `<Trans i18nKey="home:title" ns="common" /> <Trans i18nKey={'home:title'}>x</Trans>`,
+			wantCount: 2,
+		},
+		{
+			name:      "other functions are left alone",
+			content:   `translate('title'); t('title')`,
+			from:      "title",
+			to:        "heading",
+			want:      `translate('title'); t('heading')`,
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count := RenameReferences(tt.content, nil, ":", tt.from, tt.to)
+			if got != tt.want || count != tt.wantCount {
+				t.Errorf("RenameReferences() = %q, %d, want %q, %d", got, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestRenameInSources(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.js":   `t('home.title')`,
+		"other.js": `t('home.intro')`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changes, err := RenameInSources([]string{filepath.Join(dir, "*.js")}, nil, ":", "home.title", "home.heading")
+	if err != nil {
+		t.Fatalf("RenameInSources() error = %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != filepath.Join(dir, "app.js") || changes[0].Content != `t('home.heading')` || changes[0].Count != 1 {
+		t.Errorf("RenameInSources() = %+v", changes)
+	}
+
+	if _, err := RenameInSources([]string{filepath.Join(dir, "*.ts")}, nil, ":", "a", "b"); err == nil {
+		t.Error("RenameInSources() should fail for patterns matching no files")
+	}
+}
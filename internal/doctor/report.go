@@ -0,0 +1,473 @@
+package doctor
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// Severities of issues
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rule describes a kind of issue reported by the doctor
+type Rule struct {
+	ID          string
+	Severity    string
+	Description string
+}
+
+// Rules lists every rule the doctor checks
+var Rules = []Rule{
+	{"missing-key", SeverityError, "Key present in other locales is missing"},
+	{"empty-value", SeverityWarning, "Value is an empty string"},
+	{"fuzzy-translation", SeverityWarning, "Translation is flagged as needing review"},
+	{"orphan-key", SeverityWarning, "Key is missing from the source locale"},
+	{"untranslated", SeverityWarning, "Value is identical to the source locale"},
+	{"placeholder-mismatch", SeverityError, "Placeholders differ from the expected ones"},
+	{"icu-syntax", SeverityError, "Value is not a valid ICU message"},
+	{"plural-categories", SeverityError, "Plural argument lacks categories the locale needs"},
+	{"type-mismatch", SeverityError, "Value has a different type or array length than in other locales"},
+	{"unused-key", SeverityWarning, "Key is not referenced in source code"},
+	{"undefined-key", SeverityError, "Key used in source code is missing from every locale"},
+}
+
+// ReportFormats are the output formats of Run besides the default text report
+var ReportFormats = []string{"json", "sarif", "junit", "github"}
+
+// Issue is a single problem found by the doctor
+type Issue struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	File      string `json:"file"`
+	Locale    string `json:"locale,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key"`
+	Message   string `json:"message"`
+	Line      int    `json:"line,omitempty"` // 1-based, 0 if unknown
+	Column    int    `json:"column,omitempty"`
+}
+
+func ruleSeverity(id string) string {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r.Severity
+		}
+	}
+	return SeverityError
+}
+
+// Issues lists the problems in results and usage as individual issues, sorted
+// by file, line and key. Issues in JSON files carry the position of the key.
+func Issues(results map[string]CheckResult, usage *UsageResult, separator string) []Issue {
+	var issues []Issue
+	positions := make(map[string]map[string]position) // i18n.ViewID -> key -> position
+
+	add := func(file *types.I18nFile, rule, key, message string) {
+		issue := Issue{
+			Rule:      rule,
+			Severity:  ruleSeverity(rule),
+			File:      file.Path,
+			Locale:    file.Locale,
+			Namespace: file.Namespace,
+			Key:       key,
+			Message:   message,
+		}
+
+		id := i18n.ViewID(file)
+		if _, ok := positions[id]; !ok {
+			positions[id] = keyPositions(file)
+		}
+		prefix := ""
+		if file.Namespace != "" {
+			prefix = file.Namespace + separator
+		}
+		if pos, ok := positions[id][strings.TrimPrefix(key, prefix)]; ok {
+			issue.Line, issue.Column = pos.line, pos.column
+		}
+		issues = append(issues, issue)
+	}
+
+	for _, res := range results {
+		for _, k := range res.MissingKeys {
+			add(res.File, "missing-key", k, "missing key "+flatten.UnescapeKey(k))
+		}
+		for _, k := range res.EmptyKeys {
+			add(res.File, "empty-value", k, "empty value for "+flatten.UnescapeKey(k))
+		}
+		for _, k := range res.FuzzyKeys {
+			add(res.File, "fuzzy-translation", k, "fuzzy translation for "+flatten.UnescapeKey(k))
+		}
+		for _, k := range res.OrphanKeys {
+			add(res.File, "orphan-key", k, "key "+flatten.UnescapeKey(k)+" is missing from the source locale")
+		}
+		for _, k := range res.UntranslatedKeys {
+			add(res.File, "untranslated", k, "value of "+flatten.UnescapeKey(k)+" is identical to the source locale")
+		}
+		for _, p := range res.PlaceholderIssues {
+			add(res.File, "placeholder-mismatch", p.Key, p.String())
+		}
+		for _, i := range res.ICUIssues {
+			rule := "plural-categories"
+			if i.Invalid {
+				rule = "icu-syntax"
+			}
+			add(res.File, rule, i.Key, i.String())
+		}
+		for _, i := range res.TypeIssues {
+			add(res.File, "type-mismatch", i.Key, i.String())
+		}
+	}
+
+	if usage != nil {
+		// Unused keys are reported in every file defining them
+		for _, res := range results {
+			flat, err := flatten.FlattenJSON([]byte(res.File.Data), res.File.Namespace, separator)
+			if err != nil {
+				continue
+			}
+			for _, k := range usage.UnusedKeys {
+				if _, ok := flat[k]; ok {
+					add(res.File, "unused-key", k, "key "+flatten.UnescapeKey(k)+" is not referenced in source code")
+				}
+			}
+		}
+		for _, ref := range usage.UndefinedKeys {
+			issues = append(issues, Issue{
+				Rule:     "undefined-key",
+				Severity: ruleSeverity("undefined-key"),
+				File:     ref.File,
+				Key:      ref.Key,
+				Message:  "key " + ref.Key + " is missing from every locale",
+				Line:     ref.Line,
+			})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Rule < b.Rule
+	})
+	return issues
+}
+
+// WriteReport writes issues in one of ReportFormats
+func WriteReport(w io.Writer, format string, issues []Issue) error {
+	switch format {
+	case "json":
+		return writeJSONReport(w, issues)
+	case "sarif":
+		return writeSARIFReport(w, issues)
+	case "junit":
+		return writeJUnitReport(w, issues)
+	case "github":
+		return writeGitHubReport(w, issues)
+	}
+	return fmt.Errorf("unknown report format %q (supported: text, %s)", format, strings.Join(ReportFormats, ", "))
+}
+
+func writeJSONReport(w io.Writer, issues []Issue) error {
+	if issues == nil {
+		issues = []Issue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Issues []Issue `json:"issues"`
+	}{issues})
+}
+
+// SARIF 2.1.0, as understood by GitHub code scanning
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	DefaultConfig    struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func writeSARIFReport(w io.Writer, issues []Issue) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "i18nedt"
+	run.Tool.Driver.InformationURI = "https://github.com/kikyous/i18nedt"
+	for _, r := range Rules {
+		rule := sarifRule{ID: r.ID, ShortDescription: sarifMessage{r.Description}}
+		rule.DefaultConfig.Level = r.Severity
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+
+	for _, issue := range issues {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = filepathToURI(issue.File)
+		if issue.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: issue.Line, StartColumn: issue.Column}
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    issue.Rule,
+			Level:     issue.Severity,
+			Message:   sarifMessage{issue.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// filepathToURI turns a relative file path into a relative URI reference
+func filepathToURI(path string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(path, "\\", "/"), " ", "%20")
+}
+
+// JUnit XML: one test suite per file, one failed test case per issue
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnitReport(w io.Writer, issues []Issue) error {
+	report := junitSuites{Name: "i18nedt doctor"}
+	index := make(map[string]int)
+
+	for _, issue := range issues {
+		i, ok := index[issue.File]
+		if !ok {
+			i = len(report.Suites)
+			index[issue.File] = i
+			report.Suites = append(report.Suites, junitSuite{Name: issue.File})
+		}
+
+		text := fmt.Sprintf("%s: %s", issue.Severity, issue.Message)
+		switch {
+		case issue.Column > 0:
+			text = fmt.Sprintf("%s:%d:%d: %s", issue.File, issue.Line, issue.Column, text)
+		case issue.Line > 0:
+			text = fmt.Sprintf("%s:%d: %s", issue.File, issue.Line, text)
+		}
+		suite := &report.Suites[i]
+		suite.Cases = append(suite.Cases, junitCase{
+			Name:      issue.Rule + " " + issue.Key,
+			Classname: issue.File,
+			File:      issue.File,
+			Line:      issue.Line,
+			Failure:   &junitFailure{Message: issue.Message, Type: issue.Rule, Text: text},
+		})
+		suite.Tests++
+		suite.Failures++
+		report.Tests++
+		report.Failures++
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// writeGitHubReport writes GitHub Actions workflow commands, which show up as
+// annotations on pull requests
+func writeGitHubReport(w io.Writer, issues []Issue) error {
+	data := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	property := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+	for _, issue := range issues {
+		command := "error"
+		if issue.Severity == SeverityWarning {
+			command = "warning"
+		}
+		params := []string{"file=" + property.Replace(issue.File)}
+		if issue.Line > 0 {
+			params = append(params, fmt.Sprintf("line=%d", issue.Line))
+			if issue.Column > 0 {
+				params = append(params, fmt.Sprintf("col=%d", issue.Column))
+			}
+		}
+		params = append(params, "title="+property.Replace(issue.Rule))
+
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(params, ","), data.Replace(issue.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// position is a 1-based line and column, counted in characters
+type position struct {
+	line, column int
+}
+
+// keyPositions maps the flattened keys of a JSON file (without namespace) to
+// the position of the key in the file. Other formats have no positions.
+func keyPositions(file *types.I18nFile) map[string]position {
+	if i18n.FormatFor(file.Path).Name() != "JSON" {
+		return nil
+	}
+
+	offsets := make(map[string]int)
+	dec := json.NewDecoder(strings.NewReader(file.Data))
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			return nil
+		}
+
+		for i := 0; dec.More(); i++ {
+			start := skipJSONSeparators(file.Data, int(dec.InputOffset()))
+			var name string
+			if delim == '{' {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				name = flatten.EscapeKey(tok.(string))
+			} else {
+				name = fmt.Sprint(i)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			offsets[name] = start
+			if err := walk(name); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // Closing delimiter
+		return err
+	}
+	if err := walk(""); err != nil {
+		return nil
+	}
+
+	return offsetsToPositions(file.Data, offsets)
+}
+
+// skipJSONSeparators skips whitespace, commas and colons starting at offset
+func skipJSONSeparators(data string, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// offsetsToPositions converts byte offsets in data to lines and columns
+func offsetsToPositions(data string, offsets map[string]int) map[string]position {
+	lineStarts := []int{0}
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+
+	positions := make(map[string]position, len(offsets))
+	for key, offset := range offsets {
+		line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset })
+		positions[key] = position{
+			line:   line,
+			column: utf8.RuneCountInString(data[lineStarts[line-1]:offset]) + 1,
+		}
+	}
+	return positions
+}
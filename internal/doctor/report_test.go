@@ -0,0 +1,146 @@
+package doctor
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func reportFixture(t *testing.T) []Issue {
+	t.Helper()
+	files := []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: "{\n  \"title\": \"Title\",\n  \"menu\": {\n    \"file\": \"File\"\n  }\n}\n"},
+		{Path: "de/common.json", Locale: "de", Namespace: "common", Data: "{\n  \"menu\": {\"file\": \"\"},\n  \"list\": [\"a\", \"{n, plural, one {x}}\"]\n}\n"},
+		{Path: "de/app.po", Locale: "de", Namespace: "app", Data: `{"x": ""}`},
+	}
+	results, err := Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	usage := &UsageResult{UndefinedKeys: []Reference{{Key: "common:nope", File: "src/App.tsx", Line: 7}}}
+	return Issues(results, usage, ":")
+}
+
+func TestIssues(t *testing.T) {
+	issues := reportFixture(t)
+
+	want := []Issue{
+		{Rule: "empty-value", Severity: "warning", File: "de/app.po", Locale: "de", Namespace: "app", Key: "app:x", Message: "empty value for app:x"},
+		{Rule: "missing-key", Severity: "error", File: "de/common.json", Locale: "de", Namespace: "common", Key: "common:title", Message: "missing key common:title"},
+		{Rule: "empty-value", Severity: "warning", File: "de/common.json", Locale: "de", Namespace: "common", Key: "common:menu.file", Message: "empty value for common:menu.file", Line: 2, Column: 12},
+		{Rule: "icu-syntax", Severity: "error", File: "de/common.json", Locale: "de", Namespace: "common", Key: "common:list.1",
+			Message: `common:list.1: invalid ICU message: offset 19: plural argument "n" is missing the 'other' branch`, Line: 3, Column: 17},
+		{Rule: "missing-key", Severity: "error", File: "en/common.json", Locale: "en", Namespace: "common", Key: "common:list.0", Message: "missing key common:list.0"},
+		{Rule: "missing-key", Severity: "error", File: "en/common.json", Locale: "en", Namespace: "common", Key: "common:list.1", Message: "missing key common:list.1"},
+		{Rule: "undefined-key", Severity: "error", File: "src/App.tsx", Key: "common:nope", Message: "key common:nope is missing from every locale", Line: 7},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("Issues() =\n%+v\nwant\n%+v", issues, want)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	issues := reportFixture(t)
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, "json", issues); err != nil {
+			t.Fatalf("WriteReport() error = %v", err)
+		}
+		var report struct{ Issues []Issue }
+		if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+			t.Fatalf("invalid JSON report: %v", err)
+		}
+		if !reflect.DeepEqual(report.Issues, issues) {
+			t.Errorf("JSON report issues = %+v", report.Issues)
+		}
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, "sarif", issues); err != nil {
+			t.Fatalf("WriteReport() error = %v", err)
+		}
+		var log sarifLog
+		if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+			t.Fatalf("invalid SARIF report: %v", err)
+		}
+		results := log.Runs[0].Results
+		if len(results) != len(issues) || results[2].RuleID != "empty-value" || results[2].Level != "warning" {
+			t.Fatalf("SARIF results = %+v", results)
+		}
+		region := results[2].Locations[0].PhysicalLocation.Region
+		if region == nil || region.StartLine != 2 || region.StartColumn != 12 {
+			t.Errorf("SARIF region = %+v, want line 2, column 12", region)
+		}
+		if results[0].Locations[0].PhysicalLocation.Region != nil {
+			t.Error("SARIF results without a line should have no region")
+		}
+	})
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, "junit", issues); err != nil {
+			t.Fatalf("WriteReport() error = %v", err)
+		}
+		var suites junitSuites
+		if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+			t.Fatalf("invalid JUnit report: %v", err)
+		}
+		if suites.Failures != len(issues) || len(suites.Suites) != 4 || suites.Suites[1].Tests != 3 {
+			t.Errorf("JUnit report = %+v", suites)
+		}
+	})
+
+	t.Run("github", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteReport(&buf, "github", issues); err != nil {
+			t.Fatalf("WriteReport() error = %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != len(issues) {
+			t.Fatalf("GitHub report has %d lines, want %d", len(lines), len(issues))
+		}
+		want := "::warning file=de/common.json,line=2,col=12,title=empty-value::empty value for common:menu.file"
+		if lines[2] != want {
+			t.Errorf("GitHub report line = %q, want %q", lines[2], want)
+		}
+	})
+
+	if err := WriteReport(&bytes.Buffer{}, "yaml", issues); err == nil {
+		t.Error("WriteReport() should fail for an unknown format")
+	}
+}
+
+func TestIssuesShowPlainKeys(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"menu|Open": "Open", "a.b": "B"}`},
+		{Path: "de.json", Locale: "de", Data: `{"a.b": ""}`},
+	}
+	results, err := Check(files, ":")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	// Keys stay escaped to identify issues, messages show them as typed with --key
+	var got []string
+	for _, issue := range Issues(results, nil, ":") {
+		got = append(got, issue.Key+" => "+issue.Message)
+	}
+	want := []string{
+		`menu\|Open => missing key menu|Open`,
+		`a\.b => empty value for a.b`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Issues() = %q, want %q", got, want)
+	}
+
+	if got := (TypeIssue{Key: `a\.b`, Problem: "differs"}).String(); got != "a.b: differs" {
+		t.Errorf("TypeIssue.String() = %q", got)
+	}
+}
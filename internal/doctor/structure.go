@@ -0,0 +1,192 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// TypeIssue reports a key whose JSON type differs from other locales
+type TypeIssue struct {
+	Key      string
+	Problem  string
+	Conflict string // The types of the key in all locales, e.g. "a string in de, en; a number in zh"
+}
+
+func (i TypeIssue) String() string {
+	return flatten.UnescapeKey(i.Key) + ": " + i.Problem
+}
+
+// shape is the structure of a JSON value, as far as locales must agree on it
+type shape struct {
+	kind   string // object, array, string, number, boolean or null
+	length int    // Number of items of an array
+}
+
+func (s shape) String() string {
+	switch s.kind {
+	case "object":
+		return "an object"
+	case "array":
+		if s.length == 1 {
+			return "an array of 1 item"
+		}
+		return fmt.Sprintf("an array of %d items", s.length)
+	case "null":
+		return "null"
+	}
+	return "a " + s.kind
+}
+
+// collectShapes records the shape of every value below v by flattened path
+func collectShapes(v interface{}, path string, out map[string]shape) {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if path != "" {
+			out[path] = shape{kind: "object"}
+		}
+		for k, child := range v {
+			collectShapes(child, join(flatten.EscapeKey(k)), out)
+		}
+	case []interface{}:
+		if path != "" {
+			out[path] = shape{kind: "array", length: len(v)}
+		}
+		for i, child := range v {
+			collectShapes(child, join(fmt.Sprint(i)), out)
+		}
+	case string:
+		out[path] = shape{kind: "string"}
+	case float64:
+		out[path] = shape{kind: "number"}
+	case bool:
+		out[path] = shape{kind: "boolean"}
+	case nil:
+		out[path] = shape{kind: "null"}
+	}
+}
+
+// checkTypes compares the shape of every key across the files of a namespace.
+// Each file whose shape differs from the source locale, or else from most
+// locales, gets an issue. Issues are returned by i18n.ViewID of the file.
+func checkTypes(localeFiles map[string]*types.I18nFile, sourceLocale, prefix string) (map[string][]TypeIssue, error) {
+	locales := make([]string, 0, len(localeFiles))
+	for locale := range localeFiles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	shapes := make(map[string]map[string]shape) // Locale -> path -> shape
+	allPaths := make(map[string]bool)
+	for _, locale := range locales {
+		file := localeFiles[locale]
+		var data interface{}
+		if err := json.Unmarshal([]byte(file.Data), &data); err != nil {
+			return nil, fmt.Errorf("failed to parse file %s: %w", file.Path, err)
+		}
+		shapes[locale] = make(map[string]shape)
+		collectShapes(data, "", shapes[locale])
+		for path := range shapes[locale] {
+			allPaths[path] = true
+		}
+	}
+
+	paths := make([]string, 0, len(allPaths))
+	for path := range allPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	issues := make(map[string][]TypeIssue)
+	for _, path := range paths {
+		counts := make(map[shape]int)
+		var present []string
+		for _, locale := range locales {
+			if s, ok := shapes[locale][path]; ok {
+				counts[s]++
+				present = append(present, locale)
+			}
+		}
+		if len(counts) < 2 {
+			continue
+		}
+
+		want, ok := shapes[sourceLocale][path]
+		if !ok {
+			for _, locale := range present {
+				if s := shapes[locale][path]; counts[s] > counts[want] {
+					want = s
+				}
+			}
+		}
+		// Locales by shape, in order of appearance
+		var order []shape
+		byShape := make(map[shape][]string)
+		for _, locale := range present {
+			s := shapes[locale][path]
+			if byShape[s] == nil {
+				order = append(order, s)
+			}
+			byShape[s] = append(byShape[s], locale)
+		}
+		var conflict []string
+		for _, s := range order {
+			conflict = append(conflict, fmt.Sprintf("%s in %s", s, strings.Join(byShape[s], ", ")))
+		}
+
+		for _, locale := range present {
+			got := shapes[locale][path]
+			if got == want {
+				continue
+			}
+			id := i18n.ViewID(localeFiles[locale])
+			issues[id] = append(issues[id], TypeIssue{
+				Key:      prefix + path,
+				Problem:  fmt.Sprintf("is %s, but %s in %s", got, want, strings.Join(byShape[want], ", ")),
+				Conflict: strings.Join(conflict, "; "),
+			})
+		}
+	}
+	return issues, nil
+}
+
+// CheckTypes compares the value types and array lengths of every key across
+// the locales of each namespace and returns the mismatches by i18n.ViewID of
+// the file
+func CheckTypes(files []*types.I18nFile, separator string) (map[string][]TypeIssue, error) {
+	groups := make(map[string]map[string]*types.I18nFile)
+	for _, file := range files {
+		if groups[file.Namespace] == nil {
+			groups[file.Namespace] = make(map[string]*types.I18nFile)
+		}
+		groups[file.Namespace][file.Locale] = file
+	}
+
+	issues := make(map[string][]TypeIssue)
+	for ns, localeFiles := range groups {
+		prefix := ""
+		if ns != "" {
+			prefix = ns + separator
+		}
+		nsIssues, err := checkTypes(localeFiles, "", prefix)
+		if err != nil {
+			return nil, err
+		}
+		for id, list := range nsIssues {
+			issues[id] = list
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,92 @@
+package doctor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestCheck_TypeMismatches(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en/home.json", Locale: "en", Namespace: "home", Data: `{"items": ["a", "b", "c"], "title": {"main": "Home"}, "count": "1", "on": true}`},
+		{Path: "de/home.json", Locale: "de", Namespace: "home", Data: `{"items": ["a", "b"], "title": "Start", "count": "1", "on": "yes"}`},
+		{Path: "zh/home.json", Locale: "zh", Namespace: "home", Data: `{"items": {"a": "x"}, "title": {"main": "首页"}, "count": 1, "on": "是"}`},
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   map[string][]string
+	}{
+		{
+			name: "majority",
+			want: map[string][]string{
+				"en/home.json": {
+					"home:items: is an array of 3 items, but an array of 2 items in de",
+					"home:on: is a boolean, but a string in de, zh",
+				},
+				"de/home.json": {
+					"home:title: is a string, but an object in en, zh",
+				},
+				"zh/home.json": {
+					"home:count: is a number, but a string in de, en",
+					"home:items: is an object, but an array of 2 items in de",
+				},
+			},
+		},
+		{
+			name:   "source locale",
+			source: "en",
+			want: map[string][]string{
+				"de/home.json": {
+					"home:items: is an array of 2 items, but an array of 3 items in en",
+					"home:on: is a string, but a boolean in en",
+					"home:title: is a string, but an object in en, zh",
+				},
+				"zh/home.json": {
+					"home:count: is a number, but a string in de, en",
+					"home:items: is an object, but an array of 3 items in en",
+					"home:on: is a string, but a boolean in en",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := CheckWithOptions(files, Options{Separator: ":", SourceLocale: tt.source})
+			if err != nil {
+				t.Fatalf("CheckWithOptions() error = %v", err)
+			}
+			for _, file := range files {
+				var got []string
+				for _, issue := range results[file.Path].TypeIssues {
+					got = append(got, issue.String())
+				}
+				if !reflect.DeepEqual(got, tt.want[file.Path]) {
+					t.Errorf("%s TypeIssues =\n%v\nwant\n%v", file.Path, got, tt.want[file.Path])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTypes(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "de.json", Locale: "de", Data: `{"n": 1, "s": "x"}`},
+		{Path: "en.json", Locale: "en", Data: `{"n": "1", "s": "y"}`},
+		{Path: "fr.json", Locale: "fr", Data: `{"n": "1"}`},
+	}
+
+	issues, err := CheckTypes(files, ":")
+	if err != nil {
+		t.Fatalf("CheckTypes() error = %v", err)
+	}
+	want := map[string][]TypeIssue{
+		"de.json": {{Key: "n", Problem: "is a number, but a string in en, fr", Conflict: "a number in de; a string in en, fr"}},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("CheckTypes() = %+v, want %+v", issues, want)
+	}
+}
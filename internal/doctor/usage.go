@@ -0,0 +1,279 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// DefaultFunctions are the translation functions looked for when scanning
+// source code, if none are configured
+var DefaultFunctions = []string{"t", "$t", "i18n.t"}
+
+// Reference is a translation key used in application source code
+type Reference struct {
+	Key    string // Key as written in code, including a namespace prefix if any
+	File   string
+	Line   int
+	Prefix bool // Key is the static start of a dynamic key, e.g. `home.${name}`
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s (%s:%d)", r.Key, r.File, r.Line)
+}
+
+// UsageResult compares the keys defined in locale files with the keys used in code
+type UsageResult struct {
+	UnusedKeys    []string    // Defined in locale files but never referenced
+	UndefinedKeys []Reference // Referenced but missing from every locale, first use of each key
+}
+
+var (
+	transTag       = regexp.MustCompile(`<Trans\b[^>]*>`)
+	transKeyAttr   = regexp.MustCompile(`\bi18nKey=(?:"([^"]*)"|'([^']*)'|\{\s*(?:"([^"]*)"|'([^']*)'|` + "`([^`$]*)`" + `)\s*\})`)
+	transNsAttr    = regexp.MustCompile(`\bns=(?:"([^"]*)"|'([^']*)')`)
+	useTranslation = regexp.MustCompile(`\buseTranslation\(\s*\[?\s*["']([^"']+)["']`)
+
+	// i18next resolves t("items", {count}) to items_one, items_other etc.
+	pluralSuffixes = []string{"zero", "one", "two", "few", "many", "other", "plural"}
+)
+
+// ScanSources finds translation calls in the files matching patterns
+func ScanSources(patterns, functions []string, separator string) ([]Reference, error) {
+	var refs []Reference
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		matches, err := doublestar.FilepathGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source pattern %s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no source files match %s", pattern)
+		}
+
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read source file %s: %w", path, err)
+			}
+			refs = append(refs, ScanSource(path, string(content), functions, separator)...)
+		}
+	}
+
+	return refs, nil
+}
+
+// ScanSource finds translation calls and <Trans i18nKey="..."> elements in
+// content. Keys without a namespace get the namespace passed to
+// useTranslation, if the file calls it.
+func ScanSource(path, content string, functions []string, separator string) []Reference {
+	if len(functions) == 0 {
+		functions = DefaultFunctions
+	}
+
+	defaultNs := ""
+	if m := useTranslation.FindStringSubmatch(content); m != nil {
+		defaultNs = m[1]
+	}
+	qualify := func(key, ns string) string {
+		if ns == "" {
+			ns = defaultNs
+		}
+		if ns == "" || strings.Contains(key, separator) {
+			return key
+		}
+		return ns + separator + key
+	}
+
+	var refs []Reference
+	add := func(offset int, key string, prefix bool) {
+		if key == "" {
+			return
+		}
+		refs = append(refs, Reference{
+			Key:    key,
+			File:   path,
+			Line:   strings.Count(content[:offset], "\n") + 1,
+			Prefix: prefix,
+		})
+	}
+
+	for _, m := range callPattern(functions).FindAllStringSubmatchIndex(content, -1) {
+		var key string
+		var offset int
+		prefix := m[8] >= 0 // String concatenated with something: 'home.' + name
+		switch {
+		case m[2] >= 0:
+			key, offset = unquoteJS(content[m[2]:m[3]]), m[2]
+		case m[4] >= 0:
+			key, offset = unquoteJS(content[m[4]:m[5]]), m[4]
+		default:
+			key, offset = content[m[6]:m[7]], m[6]
+			if i := strings.Index(key, "${"); i >= 0 {
+				key, prefix = key[:i], true
+			}
+		}
+		add(offset, qualify(key, ""), prefix)
+	}
+
+	for _, loc := range transTag.FindAllStringIndex(content, -1) {
+		tag := content[loc[0]:loc[1]]
+		m := transKeyAttr.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		key := strings.Join(m[1:], "")
+		ns := ""
+		if n := transNsAttr.FindStringSubmatch(tag); n != nil {
+			ns = n[1] + n[2]
+		}
+		add(loc[0], qualify(key, ns), false)
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Line < refs[j].Line })
+	return refs
+}
+
+// callPattern matches a call to one of functions whose first argument is a
+// string literal. Submatches are the single quoted, double quoted and
+// template literal contents, and a "+" following the literal.
+func callPattern(functions []string) *regexp.Regexp {
+	names := make([]string, len(functions))
+	for i, f := range functions {
+		names[i] = regexp.QuoteMeta(f)
+	}
+	return regexp.MustCompile(`(?:^|[^\w$.]|\bthis\.)(?:` + strings.Join(names, "|") + `)\(\s*` +
+		`(?:'((?:[^'\\\n]|\\.)*)'|"((?:[^"\\\n]|\\.)*)"|` + "`([^`]*)`" + `)\s*(\+)?`)
+}
+
+// unquoteJS resolves the common backslash escapes of a JavaScript string literal
+func unquoteJS(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// CheckUsage reports keys defined in files but never referenced, and keys
+// referenced but defined in no locale. A reference without a namespace
+// matches the key in any namespace. A reference also covers the keys below
+// it (objects returned as a whole) and its plural forms.
+func CheckUsage(files []*types.I18nFile, refs []Reference, separator string) (UsageResult, error) {
+	type definedKey struct {
+		namespace, path string
+		used            bool
+	}
+	defined := make(map[string]*definedKey)
+	for _, file := range files {
+		flat, err := flatten.FlattenJSON([]byte(file.Data), "", separator)
+		if err != nil {
+			return UsageResult{}, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+		for path := range flat {
+			key := path
+			if file.Namespace != "" {
+				key = file.Namespace + separator + path
+			}
+			defined[key] = &definedKey{namespace: file.Namespace, path: path}
+		}
+	}
+
+	var result UsageResult
+	reported := make(map[string]bool)
+	for _, ref := range refs {
+		found := false
+		for _, d := range defined {
+			if referencedBy(d.namespace, d.path, ref, separator) {
+				d.used = true
+				found = true
+			}
+		}
+		if !found && !ref.Prefix && !reported[ref.Key] {
+			reported[ref.Key] = true
+			result.UndefinedKeys = append(result.UndefinedKeys, ref)
+		}
+	}
+
+	for key, d := range defined {
+		if !d.used {
+			result.UnusedKeys = append(result.UnusedKeys, key)
+		}
+	}
+	sort.Strings(result.UnusedKeys)
+	sort.Slice(result.UndefinedKeys, func(i, j int) bool {
+		return result.UndefinedKeys[i].Key < result.UndefinedKeys[j].Key
+	})
+
+	return result, nil
+}
+
+// referencedBy reports whether the flattened key path in namespace is covered by ref
+func referencedBy(namespace, path string, ref Reference, separator string) bool {
+	refNs, refPath := splitNamespace(ref.Key, separator)
+	if refNs != "" && refNs != namespace {
+		return false
+	}
+
+	// Natural language keys like "File not found." are used as a whole
+	if !ref.Prefix && path == flatten.EscapeKey(refPath) {
+		return true
+	}
+
+	// Code uses plain dotted paths, flattened keys escape special characters
+	parts := strings.Split(refPath, ".")
+	for i, p := range parts {
+		parts[i] = flatten.EscapeKey(p)
+	}
+	refPath = strings.Join(parts, ".")
+
+	if ref.Prefix {
+		return strings.HasPrefix(path, refPath)
+	}
+	if path == refPath || strings.HasPrefix(path, refPath+".") {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(path, refPath+"_"); ok {
+		for _, suffix := range pluralSuffixes {
+			if rest == suffix {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitNamespace splits "ns:path" into namespace and path
+func splitNamespace(key, separator string) (string, string) {
+	if ns, path, ok := strings.Cut(key, separator); ok {
+		return ns, path
+	}
+	return "", key
+}
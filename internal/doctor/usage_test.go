@@ -0,0 +1,113 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestScanSource(t *testing.T) {
+	content := "import { Trans, useTranslation } from 'react-i18next'\n" +
+		"const { t } = useTranslation('common')\n" +
+		"t('home.title')\n" +
+		"t(\"home.desc\", { name })\n" +
+		"i18n.t('errors:notFound')\n" +
+		"t(`status.${code}`) + t('menu.' + item)\n" +
+		"format('ignored') + this.$t('vue.title')\n" +
+		"<Trans i18nKey=\"welcome\" ns=\"landing\" />\n" +
+		"<Trans i18nKey={'footer.copy'}>text</Trans>\n" +
+		"t(variable)\n"
+
+	refs := ScanSource("App.tsx", content, nil, ":")
+
+	want := []Reference{
+		{Key: "common:home.title", File: "App.tsx", Line: 3},
+		{Key: "common:home.desc", File: "App.tsx", Line: 4},
+		{Key: "errors:notFound", File: "App.tsx", Line: 5},
+		{Key: "common:status.", File: "App.tsx", Line: 6, Prefix: true},
+		{Key: "common:menu.", File: "App.tsx", Line: 6, Prefix: true},
+		{Key: "common:vue.title", File: "App.tsx", Line: 7},
+		{Key: "landing:welcome", File: "App.tsx", Line: 8},
+		{Key: "common:footer.copy", File: "App.tsx", Line: 9},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("ScanSource() =\n%v\nwant\n%v", refs, want)
+	}
+}
+
+func TestScanSource_CustomFunctions(t *testing.T) {
+	content := "translate('a')\nt('b')\nobj.translate('c')\n"
+
+	refs := ScanSource("main.js", content, []string{"translate"}, ":")
+	if len(refs) != 1 || refs[0].Key != "a" {
+		t.Errorf("ScanSource() = %v, want only key a", refs)
+	}
+}
+
+func TestCheckUsage(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: `{
+			"title": "Title",
+			"old": "Unused",
+			"items_one": "{{count}} item",
+			"items_other": "{{count}} items",
+			"status": {"ok": "OK", "error": "Error"},
+			"menu": {"file": "File", "edit": "Edit"},
+			"File not found.": "File not found."
+		}`},
+		{Path: "en/admin.json", Locale: "en", Namespace: "admin", Data: `{"title": "Admin", "stats": "Stats"}`},
+	}
+	refs := []Reference{
+		{Key: "common:title", File: "a.ts", Line: 1},
+		{Key: "common:items", File: "a.ts", Line: 2},
+		{Key: "common:status.", File: "a.ts", Line: 3, Prefix: true},
+		{Key: "menu", File: "a.ts", Line: 4},
+		{Key: "File not found.", File: "a.ts", Line: 5},
+		{Key: "admin:missing", File: "b.ts", Line: 1},
+		{Key: "admin:missing", File: "b.ts", Line: 9},
+		{Key: "admin:dynamic.", File: "b.ts", Line: 2, Prefix: true},
+	}
+
+	result, err := CheckUsage(files, refs, ":")
+	if err != nil {
+		t.Fatalf("CheckUsage() error = %v", err)
+	}
+
+	wantUnused := []string{"admin:stats", "admin:title", "common:old"}
+	if !reflect.DeepEqual(result.UnusedKeys, wantUnused) {
+		t.Errorf("CheckUsage() UnusedKeys = %v, want %v", result.UnusedKeys, wantUnused)
+	}
+	wantUndefined := []Reference{{Key: "admin:missing", File: "b.ts", Line: 1}}
+	if !reflect.DeepEqual(result.UndefinedKeys, wantUndefined) {
+		t.Errorf("CheckUsage() UndefinedKeys = %v, want %v", result.UndefinedKeys, wantUndefined)
+	}
+}
+
+func TestScanSources(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src", "pages"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "src", "App.tsx"), []byte("t('a')"), 0644)
+	os.WriteFile(filepath.Join(dir, "src", "pages", "Home.vue"), []byte("<p>{{ $t('b') }}</p>"), 0644)
+	os.WriteFile(filepath.Join(dir, "src", "style.css"), []byte("t('c')"), 0644)
+
+	refs, err := ScanSources([]string{filepath.Join(dir, "src", "**", "*.{tsx,vue}")}, nil, ":")
+	if err != nil {
+		t.Fatalf("ScanSources() error = %v", err)
+	}
+	var keys []string
+	for _, ref := range refs {
+		keys = append(keys, ref.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("ScanSources() keys = %v, want [a b]", keys)
+	}
+
+	if _, err := ScanSources([]string{filepath.Join(dir, "nothing", "*.ts")}, nil, ":"); err == nil {
+		t.Error("ScanSources() should fail when a pattern matches nothing")
+	}
+}
@@ -0,0 +1,136 @@
+package editor
+
+import (
+	"fmt"
+
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// RenameKey moves the value of key from to key to in every locale. Keys are
+// prefixed with their namespace if any, and the target namespace must have a
+// file for every locale the key exists in. Values keep their JSON type,
+// renaming an object moves all keys below it, and metadata the file format
+// keeps for the key, like ARB "@key" entries, moves with it.
+func RenameKey(files []*types.I18nFile, from, to, separator string) error {
+	fromNs, fromKey := splitNamespaceKey(from, separator)
+	toNs, toKey := splitNamespaceKey(to, separator)
+	if fromKey == "" || toKey == "" {
+		return fmt.Errorf("cannot rename %s to %s: empty key", from, to)
+	}
+	if fromNs == toNs && fromKey == toKey {
+		return nil
+	}
+
+	type move struct {
+		source, target *types.I18nFile
+		raw            string
+	}
+	var moves []move
+	for _, file := range files {
+		if file.Namespace != fromNs {
+			continue
+		}
+		raw, exists, err := i18n.GetRawValue(file.Data, fromKey)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+		if !exists {
+			continue
+		}
+
+		target := findFile(files, file.Locale, toNs)
+		if target == nil {
+			return fmt.Errorf("cannot move %s: no file for namespace %q in locale %s", from, toNs, file.Locale)
+		}
+		if _, taken, err := i18n.GetRawValue(target.Data, toKey); err != nil {
+			return fmt.Errorf("failed to read %s: %w", target.Path, err)
+		} else if taken {
+			return fmt.Errorf("cannot rename %s to %s: the key already exists in %s", from, to, target.Path)
+		}
+		moves = append(moves, move{source: file, target: target, raw: raw})
+	}
+	if len(moves) == 0 {
+		return fmt.Errorf("cannot rename %s: key not found", from)
+	}
+
+	// Everything is checked before any file changes
+	for _, m := range moves {
+		data, err := i18n.DeleteValue(m.source.Data, fromKey)
+		if err != nil {
+			return err
+		}
+		m.source.Data = data
+		m.source.Dirty = true
+
+		data, err = i18n.SetRawValue(m.target.Data, toKey, m.raw)
+		if err != nil {
+			return err
+		}
+		m.target.Data = data
+		m.target.Dirty = true
+		i18n.RenameKeyMetadata(m.source, m.target, fromKey, toKey)
+	}
+	return nil
+}
+
+// findFile returns the file of locale in namespace
+func findFile(files []*types.I18nFile, locale, namespace string) *types.I18nFile {
+	for _, file := range files {
+		if file.Locale == locale && file.Namespace == namespace {
+			return file
+		}
+	}
+	return nil
+}
+
+// LookupKey returns the raw JSON value of key by locale, for the locales that
+// define it. The key is prefixed with its namespace if any.
+func LookupKey(files []*types.I18nFile, key, separator string) (map[string]string, error) {
+	ns, path := splitNamespaceKey(key, separator)
+	values := make(map[string]string)
+	for _, file := range files {
+		if file.Namespace != ns {
+			continue
+		}
+		raw, exists, err := i18n.GetRawValue(file.Data, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Path, err)
+		}
+		if exists {
+			values[file.Locale] = raw
+		}
+	}
+	return values, nil
+}
+
+// SetKey sets key, prefixed with its namespace if any, to value in the file of
+// locale. Unlike ApplyChanges, a missing key is created even for an empty
+// value. It reports whether the namespace has a file for the locale.
+func SetKey(files []*types.I18nFile, key, locale string, value *types.Value, separator string) (bool, error) {
+	ns, path := splitNamespaceKey(key, separator)
+	file := findFile(files, locale, ns)
+	if file == nil {
+		return false, nil
+	}
+
+	_, exists, err := i18n.GetRawValue(file.Data, path)
+	if err != nil {
+		return true, fmt.Errorf("failed to read %s: %w", file.Path, err)
+	}
+	if exists {
+		if current, err := i18n.GetValueTyped(file.Data, path); err == nil && current.Value == value.Value && current.Type == value.Type {
+			return true, nil
+		}
+	}
+
+	data, err := i18n.SetValueTyped(file.Data, path, value)
+	if err != nil {
+		return true, fmt.Errorf("failed to set %s in %s: %w", key, file.Path, err)
+	}
+	if data != file.Data {
+		file.Data = data
+		file.Dirty = true
+	}
+	return true, nil
+}
@@ -0,0 +1,282 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func renameFiles() []*types.I18nFile {
+	return []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: `{"title":"Title","count":3,"nav":{"home":"Home","back":"Back"}}`},
+		{Path: "de/common.json", Locale: "de", Namespace: "common", Data: `{"title":"Titel","nav":{"home":"Start"}}`},
+		{Path: "en/home.json", Locale: "en", Namespace: "home", Data: `{"intro":"Hi"}`},
+		{Path: "de/home.json", Locale: "de", Namespace: "home", Data: `{}`},
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     map[string]string // Path -> data after the rename
+		wantErr  string
+	}{
+		{
+			name: "within namespace",
+			from: "common:title",
+			to:   "common:heading",
+			want: map[string]string{
+				"en/common.json": `{"count":3,"nav":{"home":"Home","back":"Back"},"heading":"Title"}`,
+				"de/common.json": `{"nav":{"home":"Start"},"heading":"Titel"}`,
+			},
+		},
+		{
+			name: "across namespaces",
+			from: "common:title",
+			to:   "home:title",
+			want: map[string]string{
+				"en/common.json": `{"count":3,"nav":{"home":"Home","back":"Back"}}`,
+				"en/home.json":   `{"intro":"Hi","title":"Title"}`,
+				"de/home.json":   `{"title":"Titel"}`,
+			},
+		},
+		{
+			name: "object with children",
+			from: "common:nav",
+			to:   "home:menu.nav",
+			want: map[string]string{
+				"en/home.json": `{"intro":"Hi","menu":{"nav":{"home":"Home","back":"Back"}}}`,
+				"de/home.json": `{"menu":{"nav":{"home":"Start"}}}`,
+			},
+		},
+		{
+			name: "keeps numbers",
+			from: "common:count",
+			to:   "common:total",
+			want: map[string]string{
+				"en/common.json": `{"title":"Title","nav":{"home":"Home","back":"Back"},"total":3}`,
+			},
+		},
+		{name: "missing key", from: "common:nope", to: "common:other", wantErr: "key not found"},
+		{name: "existing target", from: "common:title", to: "common:count", wantErr: "already exists"},
+		{name: "missing namespace", from: "common:title", to: "auth:title", wantErr: `no file for namespace "auth"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renameFiles()
+			err := RenameKey(files, tt.from, tt.to, ":")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("RenameKey() error = %v, want %q", err, tt.wantErr)
+				}
+				for i, file := range renameFiles() {
+					if files[i].Data != file.Data {
+						t.Errorf("RenameKey() changed %s after an error", file.Path)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenameKey() error = %v", err)
+			}
+			for _, file := range files {
+				if want, ok := tt.want[file.Path]; ok {
+					if file.Data != want || !file.Dirty {
+						t.Errorf("RenameKey() %s = %s (dirty %v), want %s", file.Path, file.Data, file.Dirty, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenameKeyMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		from, to string
+		want     string
+	}{
+		{
+			name:    "ARB metadata",
+			file:    "app_en.arb",
+			content: `{"@@locale":"en","greeting":"Hello {name}","@greeting":{"description":"Home screen","placeholders":{"name":{}}},"items":"Items"}`,
+			from:    "greeting",
+			to:      "welcome",
+			want:    `{"@@locale":"en","welcome":"Hello {name}","@welcome":{"description":"Home screen","placeholders":{"name":{}}},"items":"Items"}`,
+		},
+		{
+			name:    "PO comments",
+			file:    "de.po",
+			content: "#. Home screen\n#: src/app.c:1\nmsgid \"Hello\"\nmsgstr \"Hallo\"\n\nmsgid \"Bye\"\nmsgstr \"Tschüss\"\n",
+			from:    "Hello",
+			to:      "Hi",
+			want:    "#. Home screen\n#: src/app.c:1\nmsgid \"Hi\"\nmsgstr \"Hallo\"\n\nmsgid \"Bye\"\nmsgstr \"Tschüss\"\n",
+		},
+		{
+			name:    "xcstrings comment",
+			file:    "Localizable.xcstrings",
+			content: `{"sourceLanguage":"en","strings":{"Hello":{"comment":"Greeting","localizations":{"de":{"stringUnit":{"state":"translated","value":"Hallo"}}}}},"version":"1.0"}`,
+			from:    "Hello",
+			to:      "Hi",
+			want:    `{"sourceLanguage":"en","strings":{"Hi":{"comment":"Greeting","localizations":{"en":{"stringUnit":{"state":"translated","value":"Hello"}},"de":{"stringUnit":{"state":"translated","value":"Hallo"}}}}},"version":"1.0"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			files, err := i18n.LoadAllFiles([]types.FileSource{{Path: path}})
+			if err != nil {
+				t.Fatalf("LoadAllFiles() error = %v", err)
+			}
+
+			// A rename of copies, as done for a preview, leaves the files alone
+			copies := make([]*types.I18nFile, len(files))
+			for i, file := range files {
+				c := *file
+				copies[i] = &c
+			}
+			if err := RenameKey(copies, tt.from, tt.to, ":"); err != nil {
+				t.Fatalf("RenameKey() error = %v", err)
+			}
+			for _, file := range files {
+				file.Dirty = true
+			}
+			if _, err := i18n.SaveAllFiles(files); err != nil {
+				t.Fatalf("SaveAllFiles() error = %v", err)
+			}
+			if got, _ := os.ReadFile(path); compact(string(got)) != compact(tt.content) {
+				t.Errorf("saved after a preview = %s, want %s", got, tt.content)
+			}
+
+			if err := RenameKey(files, tt.from, tt.to, ":"); err != nil {
+				t.Fatalf("RenameKey() error = %v", err)
+			}
+			if _, err := i18n.SaveAllFiles(files); err != nil {
+				t.Fatalf("SaveAllFiles() error = %v", err)
+			}
+			if got, _ := os.ReadFile(path); compact(string(got)) != compact(tt.want) {
+				t.Errorf("saved = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// compact removes the formatting whitespace of JSON files
+func compact(content string) string {
+	if !strings.HasPrefix(content, "{") {
+		return content
+	}
+	return strings.NewReplacer(" ", "", "\n", "").Replace(content)
+}
+
+func TestTempFileRenames(t *testing.T) {
+	content := "#> common:title -> home:title\n# home:title\n* en\nNew title\n"
+	temp, err := ParseTempFileContent(content, []string{"en", "de"})
+	if err != nil {
+		t.Fatalf("ParseTempFileContent() error = %v", err)
+	}
+	want := []types.KeyRename{{From: "common:title", To: "home:title"}}
+	if !reflect.DeepEqual(temp.Renames, want) {
+		t.Errorf("ParseTempFileContent() Renames = %v, want %v", temp.Renames, want)
+	}
+
+	// Renames are applied before the values of the new key are set
+	files := renameFiles()
+	temp.Separator = ":"
+	if err := ApplyChanges(files, temp); err != nil {
+		t.Fatalf("ApplyChanges() error = %v", err)
+	}
+	if got := files[2].Data; got != `{"intro":"Hi","title":"New title"}` {
+		t.Errorf("ApplyChanges() en/home.json = %s", got)
+	}
+	if got := files[3].Data; got != `{"title":"Titel"}` {
+		t.Errorf("ApplyChanges() de/home.json = %s", got)
+	}
+
+	generated, err := GenerateTempFileContentWithOptions(&types.TempFile{Renames: want}, true)
+	if err != nil {
+		t.Fatalf("GenerateTempFileContentWithOptions() error = %v", err)
+	}
+	if string(generated) != "#> common:title -> home:title\n" {
+		t.Errorf("GenerateTempFileContentWithOptions() = %q", generated)
+	}
+
+	if _, err := ParseTempFileContent("#> common:title\n", nil); err == nil {
+		t.Error("ParseTempFileContent() should reject a rename without a target")
+	}
+}
+
+func TestLookupKey(t *testing.T) {
+	files := renameFiles()
+	tests := []struct {
+		key  string
+		want map[string]string
+	}{
+		{"common:title", map[string]string{"en": `"Title"`, "de": `"Titel"`}},
+		{"common:count", map[string]string{"en": "3"}},
+		{"common:nav", map[string]string{"en": `{"home":"Home","back":"Back"}`, "de": `{"home":"Start"}`}},
+		{"home:title", map[string]string{}},
+		{"title", map[string]string{}},
+	}
+	for _, tt := range tests {
+		got, err := LookupKey(files, tt.key, ":")
+		if err != nil {
+			t.Fatalf("LookupKey(%q) error = %v", tt.key, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("LookupKey(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSetKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		locale    string
+		value     *types.Value
+		wantFound bool
+		wantData  string // Data of the file of the locale, if found
+	}{
+		{"new key with an empty value", "common:subtitle", "de", types.NewStringValue(""), true, `{"title":"Titel","nav":{"home":"Start"},"subtitle":""}`},
+		{"existing key", "common:title", "de", types.NewStringValue("Überschrift"), true, `{"title":"Überschrift","nav":{"home":"Start"}}`},
+		{"JSON value", "home:count", "en", types.NewJSONValue("3"), true, `{"intro":"Hi","count":3}`},
+		{"no file for the locale", "common:title", "fr", types.NewStringValue("Titre"), false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renameFiles()
+			found, err := SetKey(files, tt.key, tt.locale, tt.value, ":")
+			if err != nil {
+				t.Fatalf("SetKey() error = %v", err)
+			}
+			if found != tt.wantFound {
+				t.Fatalf("SetKey() found = %v, want %v", found, tt.wantFound)
+			}
+			ns, _ := splitNamespaceKey(tt.key, ":")
+			if file := findFile(files, tt.locale, ns); file != nil && (file.Data != tt.wantData || !file.Dirty) {
+				t.Errorf("SetKey() %s = %s (dirty %v), want %s", file.Path, file.Data, file.Dirty, tt.wantData)
+			}
+		})
+	}
+
+	// Setting the current value leaves the file clean
+	files := renameFiles()
+	if _, err := SetKey(files, "common:title", "en", types.NewStringValue("Title"), ":"); err != nil || files[0].Dirty {
+		t.Errorf("SetKey() with the current value: error = %v, dirty = %v", err, files[0].Dirty)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/internal/icu"
 	"github.com/kikyous/i18nedt/pkg/types"
 	"github.com/tidwall/gjson"
 )
@@ -31,6 +32,7 @@ func CreateTempFile(files []*types.I18nFile, keys []string, separator string) (*
 		Content:   make(map[string]map[string]*types.Value),
 		Deletes:   []string{},
 		Separator: separator,
+		Context:   make(map[string]string),
 	}
 
 	// Iterate over requested keys
@@ -86,6 +88,10 @@ func CreateTempFile(files []*types.I18nFile, keys []string, separator string) (*
 				// This is correct.
 				temp.Content[displayKey][file.Locale] = value
 			}
+
+			if desc := i18n.KeyDescription(file, reqKey); desc != "" {
+				temp.Context[displayKey] = desc
+			}
 		}
 	}
 
@@ -105,6 +111,8 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 	if !noTips {
 		builder.WriteString("you are a md file translator, add missing translations to this file.\n")
 		builder.WriteString("key start with # and language start with * or +.\n")
+		builder.WriteString("language start with ~ for icu plural/select messages, keep one branch per line.\n")
+		builder.WriteString("values between ``` lines are kept exactly, including blank lines and indentation.\n")
 		builder.WriteString("do not read or edit other file.(this is a tip for ai)\n\n")
 	}
 
@@ -117,6 +125,11 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 
 	for _, key := range keys {
 		builder.WriteString(fmt.Sprintf("# %s\n", key))
+		if context := temp.Context[key]; context != "" {
+			for _, line := range strings.Split(context, "\n") {
+				builder.WriteString(fmt.Sprintf("// %s\n", line))
+			}
+		}
 
 		localeValues := temp.Content[key]
 
@@ -132,9 +145,12 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 
 			// Use appropriate marker based on value type
 			var marker string
-			switch value.Type {
-			case types.ValueTypeJSON:
+			layout, isICU := icuLayout(value)
+			switch {
+			case value.Type == types.ValueTypeJSON:
 				marker = "+"
+			case isICU:
+				marker = "~"
 			default:
 				marker = "*"
 			}
@@ -142,7 +158,10 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 			builder.WriteString(fmt.Sprintf("%s %s\n", marker, locale))
 
 			// For JSON values, format with proper indentation
-			if value.Type == types.ValueTypeJSON {
+			if isICU {
+				builder.WriteString(layout)
+				builder.WriteString("\n")
+			} else if value.Type == types.ValueTypeJSON {
 				var formattedJSON []byte
 				if gjson.Valid(value.Value) {
 					formattedJSON, _ = json.MarshalIndent(gjson.Parse(value.Value).Value(), "", "  ")
@@ -151,6 +170,11 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 				}
 				builder.WriteString(string(formattedJSON))
 				builder.WriteString("\n")
+			} else if needsFence(value.Value) {
+				fence := fenceFor(value.Value)
+				builder.WriteString(fence + "\n")
+				builder.WriteString(value.Value)
+				builder.WriteString("\n" + fence + "\n")
 			} else {
 				builder.WriteString(value.Value)
 				builder.WriteString("\n")
@@ -159,7 +183,10 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 		}
 	}
 
-	// Add deletion markers
+	// Add rename and deletion markers
+	for _, r := range temp.Renames {
+		builder.WriteString(fmt.Sprintf("#> %s -> %s\n", r.From, r.To))
+	}
 	for _, deleteKey := range temp.Deletes {
 		builder.WriteString(fmt.Sprintf("#- %s\n", deleteKey))
 	}
@@ -167,6 +194,71 @@ func GenerateTempFileContentWithOptions(temp *types.TempFile, noTips bool) ([]by
 	return []byte(builder.String()), nil
 }
 
+// icuLayout returns a string value that is an ICU plural or select message
+// laid out one branch per line, if the message can be restored exactly from
+// the layout after editing
+func icuLayout(value *types.Value) (string, bool) {
+	if value.Type != types.ValueTypeString || !strings.Contains(value.Value, "{") || strings.Contains(value.Value, "\n") {
+		return "", false
+	}
+	msg, err := icu.Parse(value.Value)
+	if err != nil || !msg.IsComplex() {
+		return "", false
+	}
+
+	// Lines are trimmed when parsing, and must not look like markers
+	layout := msg.Layout()
+	lines := strings.Split(layout, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+		if lines[i] == "" || strings.ContainsAny(lines[i][:1], "#*+~") || strings.HasPrefix(lines[i], "//") {
+			return "", false
+		}
+	}
+	restored, err := icu.Parse(strings.Join(lines, "\n"))
+	if err != nil || restored.String() != value.Value {
+		return "", false
+	}
+	return layout, true
+}
+
+// needsFence reports whether a string value would change when written as
+// plain lines, which are trimmed, skipped if blank or comments, and taken as
+// markers if they start with one
+func needsFence(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, line := range strings.Split(value, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != line || trimmed == "" || strings.ContainsAny(trimmed[:1], "#*+~") ||
+			strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "```") {
+			return true
+		}
+	}
+	return false
+}
+
+// fenceFor returns a line of backticks that does not occur in value
+func fenceFor(value string) string {
+	fence := "```"
+	for {
+		clash := false
+		for _, line := range strings.Split(value, "\n") {
+			clash = clash || strings.TrimSpace(line) == fence
+		}
+		if !clash {
+			return fence
+		}
+		fence += "`"
+	}
+}
+
+// isFence reports whether a trimmed line opens or closes a fenced value
+func isFence(line string) bool {
+	return len(line) >= 3 && strings.Trim(line, "`") == ""
+}
+
 // WriteTempFile writes the temporary file
 func WriteTempFile(temp *types.TempFile) error {
 	return WriteTempFileWithOptions(temp, false)
@@ -182,7 +274,69 @@ func WriteTempFileWithOptions(temp *types.TempFile, noTips bool) error {
 	return os.WriteFile(temp.Path, content, 0644)
 }
 
-// ParseTempFileContent parses the content of the edited temporary file
+// errorAnnotationPrefix marks lines inserted by AnnotateParseError.
+// They start with "//" so the parser skips them like any other comment.
+const errorAnnotationPrefix = "// i18nedt error: "
+
+// ParseError describes a problem found while parsing an edited temporary file
+type ParseError struct {
+	Line int // 1-based line number, 0 if unknown
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// AnnotateParseError returns content with the parse error written as a comment
+// right above the offending line. Annotations from previous attempts are removed.
+func AnnotateParseError(content string, perr *ParseError) string {
+	lines := strings.Split(content, "\n")
+	annotated := make([]string, 0, len(lines)+1)
+	inserted := false
+	afterLocale := false // The previous non-blank line is a locale marker
+	fence := ""          // Closing fence while inside a fenced value
+
+	for i, line := range lines {
+		if i+1 == perr.Line {
+			annotated = append(annotated, errorAnnotationPrefix+perr.Err.Error())
+			inserted = true
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			// Fenced values are kept as they are
+			if trimmed == fence {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, errorAnnotationPrefix):
+			continue
+		case afterLocale && isFence(trimmed):
+			fence = trimmed
+		}
+		if trimmed != "" {
+			afterLocale = fence == "" && strings.ContainsAny(trimmed[:1], "*+~")
+		}
+		annotated = append(annotated, line)
+	}
+
+	// Errors without a usable line number go to the top of the file
+	if !inserted {
+		annotated = append([]string{errorAnnotationPrefix + perr.Err.Error()}, annotated...)
+	}
+
+	return strings.Join(annotated, "\n")
+}
+
+// ParseTempFileContent parses the content of the edited temporary file.
+// Errors are returned as *ParseError carrying the offending line number.
 func ParseTempFileContent(content string, locales []string) (*types.TempFile, error) {
 	lines := strings.Split(content, "\n")
 	temp := &types.TempFile{
@@ -192,11 +346,32 @@ func ParseTempFileContent(content string, locales []string) (*types.TempFile, er
 
 	var currentKey string
 	var currentLocale string
+	var currentLine int // line of the current locale marker
 	var currentValue strings.Builder
-	var isJSONValue bool
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
+	var marker byte // Marker of the current locale: '*', '+' or '~'
+
+	// Fenced values are read verbatim up to the closing fence
+	var fence string // Closing fence while inside a fenced value
+	var fenceLine int
+	var fencedLines int
+	var fenced bool // The current value was fenced and is complete
+
+	for i, raw := range lines {
+		lineNo := i + 1
+		if fence != "" {
+			if strings.TrimSpace(raw) == fence {
+				fence = ""
+				fenced = true
+				continue
+			}
+			if fencedLines > 0 {
+				currentValue.WriteString("\n")
+			}
+			currentValue.WriteString(raw)
+			fencedLines++
+			continue
+		}
+		line := strings.TrimSpace(raw)
 
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "//") {
@@ -207,9 +382,22 @@ func ParseTempFileContent(content string, locales []string) (*types.TempFile, er
 		if strings.HasPrefix(line, "#") {
 			// Save previous value if any
 			if currentKey != "" && currentLocale != "" {
-				if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), isJSONValue); err != nil {
-					return nil, fmt.Errorf("line %d: %w", i, err)
+				if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), marker); err != nil {
+					return nil, &ParseError{Line: currentLine, Err: err}
+				}
+			}
+
+			// Handle rename marker: #> old.key -> new.key
+			if strings.HasPrefix(line, "#>") {
+				from, to, ok := strings.Cut(line[2:], "->")
+				from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+				if !ok || from == "" || to == "" {
+					return nil, &ParseError{Line: lineNo, Err: fmt.Errorf("invalid rename, expected #> old.key -> new.key")}
 				}
+				temp.Renames = append(temp.Renames, types.KeyRename{From: from, To: to})
+				currentKey = ""
+				currentLocale = ""
+				continue
 			}
 
 			// Handle deletion marker
@@ -228,32 +416,42 @@ func ParseTempFileContent(content string, locales []string) (*types.TempFile, er
 			}
 			currentLocale = ""
 			currentValue.Reset()
+			fenced = false
 			continue
 		}
 
 		// Locale line
-		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "+") {
+		if strings.HasPrefix(line, "*") || strings.HasPrefix(line, "+") || strings.HasPrefix(line, "~") {
 			// Save previous value if any
 			if currentKey != "" && currentLocale != "" {
-				if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), isJSONValue); err != nil {
-					return nil, fmt.Errorf("line %d: %w", i, err)
+				if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), marker); err != nil {
+					return nil, &ParseError{Line: currentLine, Err: err}
 				}
 			}
 
 			// New locale
 			parts := strings.Fields(line[1:])
 			if len(parts) == 0 {
-				return nil, fmt.Errorf("line %d: invalid locale format", i)
+				return nil, &ParseError{Line: lineNo, Err: fmt.Errorf("invalid locale format")}
 			}
 
 			currentLocale = parts[0]
-			isJSONValue = strings.HasPrefix(line, "+")
+			currentLine = lineNo
+			marker = line[0]
 			currentValue.Reset()
+			fenced = false
 			continue
 		}
 
 		// Value line
 		if currentKey != "" && currentLocale != "" {
+			if fenced {
+				return nil, &ParseError{Line: lineNo, Err: fmt.Errorf("unexpected text after the closing ``` of a value")}
+			}
+			if currentValue.Len() == 0 && isFence(line) {
+				fence, fenceLine, fencedLines = line, lineNo, 0
+				continue
+			}
 			if currentValue.Len() > 0 {
 				currentValue.WriteString("\n")
 			}
@@ -261,25 +459,37 @@ func ParseTempFileContent(content string, locales []string) (*types.TempFile, er
 		}
 	}
 
+	if fence != "" {
+		return nil, &ParseError{Line: fenceLine, Err: fmt.Errorf("%s block is not closed", fence)}
+	}
+
 	// Save last value
 	if currentKey != "" && currentLocale != "" {
-		if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), isJSONValue); err != nil {
-			return nil, fmt.Errorf("end of file: %w", err)
+		if err := saveValue(temp, currentKey, currentLocale, currentValue.String(), marker); err != nil {
+			return nil, &ParseError{Line: currentLine, Err: err}
 		}
 	}
 
 	return temp, nil
 }
 
-func saveValue(temp *types.TempFile, key, locale, value string, isJSON bool) error {
+func saveValue(temp *types.TempFile, key, locale, value string, marker byte) error {
 	var v *types.Value
-	if isJSON {
+	switch marker {
+	case '+':
 		// Validate JSON content
 		if !gjson.Valid(value) {
 			return fmt.Errorf("invalid JSON content for key '%s', locale '%s'", key, locale)
 		}
 		v = types.NewJSONValue(value)
-	} else {
+	case '~':
+		// Join the branches laid out on separate lines back into one message
+		msg, err := icu.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid ICU message for key '%s', locale '%s': %w", key, locale, err)
+		}
+		v = types.NewStringValue(msg.String())
+	default:
 		v = types.NewStringValue(value)
 	}
 
@@ -287,7 +497,8 @@ func saveValue(temp *types.TempFile, key, locale, value string, isJSON bool) err
 	return nil
 }
 
-// ReadTempFile reads and parses the temporary file
+// ReadTempFile reads and parses the temporary file.
+// Parse errors are returned as *ParseError.
 func ReadTempFile(temp *types.TempFile) error {
 	content, err := os.ReadFile(temp.Path)
 	if err != nil {
@@ -296,7 +507,7 @@ func ReadTempFile(temp *types.TempFile) error {
 
 	parsedTemp, err := ParseTempFileContent(string(content), temp.Locales)
 	if err != nil {
-		return fmt.Errorf("failed to parse temp file content: %w", err)
+		return err
 	}
 
 	// Update the original temp with the parsed content
@@ -305,10 +516,21 @@ func ReadTempFile(temp *types.TempFile) error {
 	temp.Locales = parsedTemp.Locales
 	temp.Content = parsedTemp.Content
 	temp.Deletes = parsedTemp.Deletes
+	temp.Renames = parsedTemp.Renames
 
 	return nil
 }
 
+// AnnotateTempFile writes the parse error into the temporary file as an inline comment
+func AnnotateTempFile(temp *types.TempFile, perr *ParseError) error {
+	content, err := os.ReadFile(temp.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read temporary file: %w", err)
+	}
+
+	return os.WriteFile(temp.Path, []byte(AnnotateParseError(string(content), perr)), 0644)
+}
+
 // CleanupTempFile removes the temporary file
 func CleanupTempFile(temp *types.TempFile) error {
 	if temp.Path != "" {
@@ -319,6 +541,13 @@ func CleanupTempFile(temp *types.TempFile) error {
 
 // ApplyChanges applies changes from temp file to the actual i18n files
 func ApplyChanges(files []*types.I18nFile, temp *types.TempFile) error {
+	// Handle renames first, so the new keys can be edited in the same file
+	for _, r := range temp.Renames {
+		if err := RenameKey(files, r.From, r.To, temp.Separator); err != nil {
+			return err
+		}
+	}
+
 	// Handle deletions
 	for _, keyToDelete := range temp.Deletes {
 		targetNs, targetKey := splitNamespaceKey(keyToDelete, temp.Separator)
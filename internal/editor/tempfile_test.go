@@ -1,6 +1,7 @@
 package editor
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -101,6 +102,154 @@ func TestGenerateTempFileContent(t *testing.T) {
 	}
 }
 
+func TestGenerateTempFileContentWithContext(t *testing.T) {
+	temp := &types.TempFile{
+		Locales: []string{"en"},
+		Content: map[string]map[string]*types.Value{
+			"greeting": {"en": types.NewStringValue("Hello {name}")},
+		},
+		Context: map[string]string{"greeting": "Shown on the home screen\nKeep it short"},
+	}
+
+	content, err := GenerateTempFileContentWithOptions(temp, true)
+	if err != nil {
+		t.Fatalf("GenerateTempFileContentWithOptions() error = %v", err)
+	}
+
+	want := "# greeting\n// Shown on the home screen\n// Keep it short\n* en\nHello {name}\n\n"
+	if string(content) != want {
+		t.Errorf("GenerateTempFileContentWithOptions() = %q, want %q", content, want)
+	}
+
+	// Context comments are not part of the value
+	parsed, err := ParseTempFileContent(string(content), temp.Locales)
+	if err != nil {
+		t.Fatalf("ParseTempFileContent() error = %v", err)
+	}
+	if got := parsed.Content["greeting"]["en"].Value; got != "Hello {name}" {
+		t.Errorf("ParseTempFileContent() value = %q", got)
+	}
+}
+
+func TestTempFileICUMessages(t *testing.T) {
+	plural := "{count, plural, one {# item} other {# items}}"
+	temp := &types.TempFile{
+		Locales: []string{"en", "pl"},
+		Content: map[string]map[string]*types.Value{
+			"cart": {
+				"en": types.NewStringValue(plural),
+				"pl": types.NewStringValue("Hello {name}"),
+			},
+		},
+	}
+
+	content, err := GenerateTempFileContentWithOptions(temp, true)
+	if err != nil {
+		t.Fatalf("GenerateTempFileContentWithOptions() error = %v", err)
+	}
+	want := "# cart\n~ en\n{count, plural,\n  one {# item}\n  other {# items}\n}\n\n* pl\nHello {name}\n\n"
+	if string(content) != want {
+		t.Errorf("GenerateTempFileContentWithOptions() = %q, want %q", content, want)
+	}
+
+	// Branches are edited one per line and joined back into a single message
+	edited := strings.Replace(string(content), "* pl\nHello {name}", "~ pl\n{count, plural,\none {# produkt}\n  few {# produkty}\n  many {# produktów}\n  other {# produktu}\n}", 1)
+	parsed, err := ParseTempFileContent(edited, temp.Locales)
+	if err != nil {
+		t.Fatalf("ParseTempFileContent() error = %v", err)
+	}
+	if got := parsed.Content["cart"]["en"].Value; got != plural {
+		t.Errorf("ParseTempFileContent() en = %q, want %q", got, plural)
+	}
+	wantPl := "{count, plural, one {# produkt} few {# produkty} many {# produktów} other {# produktu}}"
+	if got := parsed.Content["cart"]["pl"]; got.Value != wantPl || got.Type != types.ValueTypeString {
+		t.Errorf("ParseTempFileContent() pl = %+v, want %q", got, wantPl)
+	}
+
+	// Messages that would not survive the layout stay on one line
+	for _, value := range []string{
+		"{count,plural,one{# item}other{# items}}",
+		"{n, select, other {two\nlines}}",
+		"Plain {name}",
+	} {
+		if _, ok := icuLayout(types.NewStringValue(value)); ok {
+			t.Errorf("icuLayout(%q) should not lay out the message", value)
+		}
+	}
+
+	// Invalid messages are reported on the locale line
+	_, err = ParseTempFileContent("# cart\n~ en\n{count, plural,\none {# item}\n}\n", temp.Locales)
+	perr, ok := err.(*ParseError)
+	if !ok || perr.Line != 2 {
+		t.Errorf("ParseTempFileContent() error = %v, want *ParseError on line 2", err)
+	}
+}
+
+func TestTempFileFencedValues(t *testing.T) {
+	values := []string{
+		"  indented\n\tand tabbed",
+		"first\n\nafter a blank line",
+		"see // note\n// comment",
+		"# heading\n* item\n+ plus\n~ tilde",
+		"trailing newline\n",
+		"\nleading newline",
+		" ",
+		"```\ncode\n```",
+		"windows\r\nline",
+		"// i18nedt error: not an annotation",
+	}
+	temp := &types.TempFile{Locales: []string{"en"}, Content: make(map[string]map[string]*types.Value)}
+	for i, value := range values {
+		temp.Content[fmt.Sprintf("key%d", i)] = map[string]*types.Value{"en": types.NewStringValue(value)}
+	}
+
+	content, err := GenerateTempFileContentWithOptions(temp, false)
+	if err != nil {
+		t.Fatalf("GenerateTempFileContentWithOptions() error = %v", err)
+	}
+	parsed, err := ParseTempFileContent(string(content), temp.Locales)
+	if err != nil {
+		t.Fatalf("ParseTempFileContent() error = %v", err)
+	}
+	for i, value := range values {
+		if got := parsed.Content[fmt.Sprintf("key%d", i)]["en"].Value; got != value {
+			t.Errorf("round trip of %q = %q", value, got)
+		}
+	}
+
+	// Values that survive the plain format are not fenced
+	for _, value := range []string{"", "Hello", "two\nlines", "a # b"} {
+		if needsFence(value) {
+			t.Errorf("needsFence(%q) = true, want false", value)
+		}
+	}
+	if got := fenceFor("```\n````"); got != "`````" {
+		t.Errorf("fenceFor() = %q, want %q", got, "`````")
+	}
+
+	// Annotations only remove earlier annotations outside of fenced values
+	fencedAnnotation := "# key9\n* en\n```\n// i18nedt error: not an annotation\n```\n"
+	if got := AnnotateParseError(fencedAnnotation, &ParseError{Err: fmt.Errorf("boom")}); got != "// i18nedt error: boom\n"+fencedAnnotation {
+		t.Errorf("AnnotateParseError() = %q", got)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"unclosed fence", "# k\n* en\n```\nvalue\n", 3},
+		{"text after fence", "# k\n* en\n```\nvalue\n```\nmore\n", 6},
+	}
+	for _, tt := range tests {
+		_, err := ParseTempFileContent(tt.content, temp.Locales)
+		perr, ok := err.(*ParseError)
+		if !ok || perr.Line != tt.line {
+			t.Errorf("%s: ParseTempFileContent() error = %v, want *ParseError on line %d", tt.name, err, tt.line)
+		}
+	}
+}
+
 func TestWriteTempFile(t *testing.T) {
 	temp := &types.TempFile{
 		Path:    "/tmp/test-i18nedt.txt",
@@ -218,6 +367,56 @@ Existing value`
 	}
 }
 
+func TestParseTempFileContentError(t *testing.T) {
+	content := `# home
+* en-US
+Home
+
++ zh-CN
+{"broken": }
+
+# nav
+* en-US
+Nav`
+
+	_, err := ParseTempFileContent(content, []string{"en-US", "zh-CN"})
+	if err == nil {
+		t.Fatal("ParseTempFileContent() expected error for invalid JSON")
+	}
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("ParseTempFileContent() error type = %T, want *ParseError", err)
+	}
+	if perr.Line != 5 {
+		t.Errorf("ParseTempFileContent() error line = %d, want 5", perr.Line)
+	}
+}
+
+func TestAnnotateParseError(t *testing.T) {
+	content := "# home\n+ zh-CN\n{\"broken\": }\n"
+	perr := &ParseError{Line: 2, Err: fmt.Errorf("invalid JSON")}
+
+	annotated := AnnotateParseError(content, perr)
+	want := "# home\n// i18nedt error: invalid JSON\n+ zh-CN\n{\"broken\": }\n"
+	if annotated != want {
+		t.Errorf("AnnotateParseError() = %q, want %q", annotated, want)
+	}
+
+	// Annotating again replaces the old annotation instead of stacking them
+	perr = &ParseError{Line: 3, Err: fmt.Errorf("still invalid")}
+	annotated = AnnotateParseError(annotated, perr)
+	want = "# home\n// i18nedt error: still invalid\n+ zh-CN\n{\"broken\": }\n"
+	if annotated != want {
+		t.Errorf("AnnotateParseError() second pass = %q, want %q", annotated, want)
+	}
+
+	// The annotated content must still parse up to the original error
+	if _, err := ParseTempFileContent(annotated, nil); err == nil {
+		t.Error("ParseTempFileContent() expected error on annotated content")
+	}
+}
+
 func TestReadTempFile(t *testing.T) {
 	content := `# home.welcome
 * zh-CN
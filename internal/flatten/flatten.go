@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 )
 
-// FlattenJSON flattens JSON data and returns key-value pairs
+// FlattenJSON flattens JSON data and returns key-value pairs. Keys are
+// gjson/sjson paths, with the special characters of each key escaped by
+// EscapeKey; UnescapeKey turns them back into plain dotted keys for display.
 func FlattenJSON(data []byte, namespace, separator string) (map[string]string, error) {
 	// Parse JSON into interface{}
 	var result interface{}
@@ -27,6 +30,42 @@ func FlattenJSON(data []byte, namespace, separator string) (map[string]string, e
 	return flat, nil
 }
 
+// pathSpecialChars have a special meaning in gjson/sjson key paths
+const pathSpecialChars = `\.*?|#@`
+
+// EscapeKey escapes characters with a special meaning in key paths, so keys
+// like "File not found." can be addressed as a single path component
+func EscapeKey(key string) string {
+	if !strings.ContainsAny(key, pathSpecialChars) {
+		return key
+	}
+
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(pathSpecialChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// UnescapeKey reverses EscapeKey
+func UnescapeKey(key string) string {
+	if !strings.Contains(key, `\`) {
+		return key
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\\' && i+1 < len(key) {
+			i++
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
 // traverse recursively traverses JSON structure and prints paths
 func traverse(data interface{}, path, prefix string, result map[string]string) {
 	switch v := data.(type) {
@@ -41,9 +80,9 @@ func traverse(data interface{}, path, prefix string, result map[string]string) {
 
 		for _, k := range keys {
 			val := v[k]
-			newPath := k
+			newPath := EscapeKey(k)
 			if path != "" {
-				newPath = path + "." + k
+				newPath = path + "." + newPath
 			}
 			traverse(val, newPath, prefix, result)
 		}
@@ -0,0 +1,61 @@
+package flatten
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlattenJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		namespace string
+		want      map[string]string
+	}{
+		{
+			name: "nested keys",
+			data: `{"home":{"title":"Home","items":["a",1]}}`,
+			want: map[string]string{"home.title": `"Home"`, "home.items.0": `"a"`, "home.items.1": "1"},
+		},
+		{
+			name:      "namespace prefix",
+			data:      `{"title":"Home"}`,
+			namespace: "common",
+			want:      map[string]string{"common:title": `"Home"`},
+		},
+		{
+			name: "special characters are escaped",
+			data: `{"File not found.":"x","a":{"b?":"y"}}`,
+			want: map[string]string{`File not found\.`: `"x"`, `a.b\?`: `"y"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FlattenJSON([]byte(tt.data), tt.namespace, ":")
+			if err != nil {
+				t.Fatalf("FlattenJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FlattenJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnescapeKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "home.title", want: "home.title"},
+		{key: EscapeKey("File not found."), want: "File not found."},
+		{key: `a.b\?.c\\d`, want: `a.b?.c\d`},
+	}
+
+	for _, tt := range tests {
+		if got := UnescapeKey(tt.key); got != tt.want {
+			t.Errorf("UnescapeKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// androidFormat reads and writes Android string resources (res/values-*/strings.xml).
+// <string> maps to a string, <plurals> to an object keyed by quantity and
+// <string-array> to an array. Resources that did not change are written back
+// byte for byte, together with comments and attributes.
+type androidFormat struct{}
+
+// androidResource is one child element of <resources>
+type androidResource struct {
+	leading string // Whitespace and comments before the element
+	raw     string // The element as written in the file
+	name    string
+	kind    string // "string", "plurals" or "string-array"
+	value   string // Value as JSON when loaded
+}
+
+// androidState is the format state stored on I18nFile.State for Android files
+type androidState struct {
+	prefix    string // Everything up to and including the <resources> start tag
+	resources []*androidResource
+	suffix    string // Everything from the last resource on, including </resources>
+	indent    string // Indentation of resources, used for new elements
+}
+
+type androidItem struct {
+	Quantity string `xml:"quantity,attr"`
+	Inner    string `xml:",innerxml"`
+}
+
+type androidElement struct {
+	XMLName xml.Name
+	Name    string        `xml:"name,attr"`
+	Inner   string        `xml:",innerxml"`
+	Items   []androidItem `xml:"item"`
+}
+
+func (androidFormat) Name() string { return "Android XML" }
+
+func (androidFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+	content := strings.ReplaceAll(string(stripBOM(raw)), "\r\n", "\n")
+
+	state := &androidState{indent: "    "}
+	file.State = state
+	if strings.TrimSpace(content) == "" {
+		file.Data = "{}"
+		return nil
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(content))
+	depth := 0
+	last := 0
+	for {
+		offset := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				if t.Name.Local != "resources" {
+					return fmt.Errorf("root element must be <resources>, got <%s>", t.Name.Local)
+				}
+				depth++
+				last = int(dec.InputOffset())
+				state.prefix = content[:last]
+				continue
+			}
+
+			var el androidElement
+			if err := dec.DecodeElement(&el, &t); err != nil {
+				return err
+			}
+			end := int(dec.InputOffset())
+
+			res := &androidResource{
+				leading: content[last:offset],
+				raw:     content[offset:end],
+				name:    el.Name,
+				kind:    el.XMLName.Local,
+			}
+			if value, ok := androidValue(&el); ok {
+				res.value = value
+			}
+			if i := strings.LastIndex(res.leading, "\n"); i >= 0 && strings.TrimSpace(res.leading[i+1:]) == "" {
+				state.indent = res.leading[i+1:]
+			}
+			state.resources = append(state.resources, res)
+			last = end
+
+		case xml.EndElement:
+			state.suffix = content[last:]
+			depth = 0
+		}
+	}
+
+	// Build JSON data in file order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, res := range state.resources {
+		if res.value == "" {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(res.name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.WriteString(res.value)
+	}
+	buf.WriteByte('}')
+	file.Data = buf.String()
+	return nil
+}
+
+func (androidFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	style.FinalNewline = true
+
+	state, _ := file.State.(*androidState)
+	if state == nil || state.prefix == "" {
+		state = &androidState{
+			prefix: "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<resources>",
+			suffix: "\n</resources>\n",
+			indent: "    ",
+		}
+	}
+
+	values := make(map[string]gjson.Result)
+	var order []string
+	gjson.Parse(file.Data).ForEach(func(k, v gjson.Result) bool {
+		values[k.String()] = v
+		order = append(order, k.String())
+		return true
+	})
+
+	var out strings.Builder
+	out.WriteString(state.prefix)
+	seen := make(map[string]bool)
+
+	for _, res := range state.resources {
+		if res.value == "" {
+			// Not a translatable resource (e.g. <dimen>), keep as is
+			out.WriteString(res.leading + res.raw)
+			continue
+		}
+
+		v, ok := values[res.name]
+		if !ok {
+			continue // Deleted
+		}
+		seen[res.name] = true
+
+		if compactJSON(v.Raw) == res.value {
+			out.WriteString(res.leading + res.raw)
+			continue
+		}
+
+		el, err := androidElementFor(res.name, v, state.indent, res.raw)
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString(res.leading + el)
+	}
+
+	for _, key := range order {
+		if seen[key] {
+			continue
+		}
+		el, err := androidElementFor(key, values[key], state.indent, "")
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("\n" + state.indent + el)
+	}
+
+	out.WriteString(state.suffix)
+	return applyLineStyle([]byte(out.String()), style), nil
+}
+
+// isAndroidValuesPattern reports whether the locale placeholder of a pattern
+// names an Android resource directory (res/values-{{language}}/strings.xml)
+func isAndroidValuesPattern(pattern string) bool {
+	return strings.Contains(pattern, "values-{{language}}") || strings.Contains(pattern, "values-{{locale}}")
+}
+
+// androidQualifierToLocale converts an Android resource qualifier (pt-rBR,
+// b+sr+Latn) to a BCP 47 tag (pt-BR, sr-Latn)
+func androidQualifierToLocale(qualifier string) string {
+	if strings.HasPrefix(qualifier, "b+") {
+		return strings.ReplaceAll(qualifier[2:], "+", "-")
+	}
+	if parts := strings.Split(qualifier, "-"); len(parts) == 2 && len(parts[1]) == 3 && parts[1][0] == 'r' {
+		return parts[0] + "-" + parts[1][1:]
+	}
+	return qualifier
+}
+
+// localeToAndroidQualifier converts a BCP 47 tag to an Android resource qualifier
+func localeToAndroidQualifier(locale string) string {
+	parts := strings.Split(locale, "-")
+	switch {
+	case len(parts) == 1:
+		return locale
+	case len(parts) == 2 && len(parts[1]) == 2:
+		return parts[0] + "-r" + strings.ToUpper(parts[1])
+	}
+	return "b+" + strings.Join(parts, "+")
+}
+
+// androidValue converts a resource element into a JSON value
+func androidValue(el *androidElement) (string, bool) {
+	var v interface{}
+	switch el.XMLName.Local {
+	case "string":
+		v = androidUnescape(el.Inner)
+	case "plurals":
+		plural := make(map[string]string)
+		var keys []string
+		for _, item := range el.Items {
+			plural[item.Quantity] = androidUnescape(item.Inner)
+			keys = append(keys, item.Quantity)
+		}
+		return orderedObject(keys, plural), true
+	case "string-array":
+		items := make([]string, len(el.Items))
+		for i, item := range el.Items {
+			items[i] = androidUnescape(item.Inner)
+		}
+		v = items
+	default:
+		return "", false
+	}
+
+	raw, _ := json.Marshal(v)
+	return compactJSON(string(raw)), true
+}
+
+// androidElementFor renders a resource element for a JSON value. The start
+// tag of the existing element is reused so attributes are kept.
+func androidElementFor(name string, v gjson.Result, indent, old string) (string, error) {
+	var kind, inner string
+	switch {
+	case v.IsObject():
+		kind = "plurals"
+		var b strings.Builder
+		v.ForEach(func(q, item gjson.Result) bool {
+			fmt.Fprintf(&b, "\n%s%s<item quantity=\"%s\">%s</item>", indent, indent, xmlAttrEscape(q.String()), androidEscape(item.String()))
+			return true
+		})
+		inner = b.String() + "\n" + indent
+	case v.IsArray():
+		kind = "string-array"
+		var b strings.Builder
+		for _, item := range v.Array() {
+			fmt.Fprintf(&b, "\n%s%s<item>%s</item>", indent, indent, androidEscape(item.String()))
+		}
+		inner = b.String() + "\n" + indent
+	default:
+		kind = "string"
+		inner = androidEscape(v.String())
+	}
+
+	start := fmt.Sprintf("<%s name=\"%s\">", kind, xmlAttrEscape(name))
+	if m := androidStartTag.FindString(old); m != "" && strings.HasPrefix(m, "<"+kind+" ") {
+		start = m
+	}
+	return start + inner + "</" + kind + ">", nil
+}
+
+var androidStartTag = regexp.MustCompile(`^<[^>]*[^/]>`)
+
+// Android resource strings use backslash escapes on top of XML escaping
+var androidEscapes = map[byte]string{'n': "\n", 't': "\t", '\'': "'", '"': "\"", '\\': "\\", '@': "@", '?': "?"}
+
+// androidUnescape decodes the inner XML of a string resource. Markup such as
+// <b> is kept as written; entities and backslash escapes are decoded.
+func androidUnescape(inner string) string {
+	s := strings.TrimSpace(inner)
+	if strings.HasPrefix(s, "<![CDATA[") && strings.HasSuffix(s, "]]>") {
+		return s[9 : len(s)-3]
+	}
+	s = xmlEntityUnescape(s)
+
+	// A fully double-quoted string keeps its content literally
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' && !strings.HasSuffix(s, `\"`) {
+		s = s[1 : len(s)-1]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			if r, ok := androidEscapes[s[i+1]]; ok {
+				b.WriteString(r)
+				i++
+				continue
+			}
+			if s[i+1] == 'u' && i+6 <= len(s) {
+				var code int
+				if _, err := fmt.Sscanf(s[i+2:i+6], "%04x", &code); err == nil {
+					b.WriteRune(rune(code))
+					i += 5
+					continue
+				}
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// androidEscape encodes a value for a string resource, keeping the styling
+// markup Android supports
+func androidEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '\'':
+			b.WriteString(`\'`)
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case (c == '@' || c == '?') && i == 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '&' && !xmlEntityStart.MatchString(s[i:]):
+			b.WriteString("&amp;")
+		case c == '<' && !xmlTagStart.MatchString(s[i:]):
+			b.WriteString("&lt;")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+var (
+	xmlEntity      = regexp.MustCompile(`&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+	xmlEntityStart = regexp.MustCompile(`^&(#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`)
+	xmlTagStart    = regexp.MustCompile(`^</?(a|annotation|b|big|br|em|font|i|li|p|s|small|span|strike|strong|sub|sup|tt|u|ul|xliff:g)\b[^<]*>`)
+)
+
+// xmlEntityUnescape decodes XML entities, leaving markup untouched
+func xmlEntityUnescape(s string) string {
+	return xmlEntity.ReplaceAllStringFunc(s, func(m string) string {
+		var out string
+		if err := xml.Unmarshal([]byte("<x>"+m+"</x>"), &out); err != nil {
+			return m
+		}
+		return out
+	})
+}
+
+// xmlTextEscape escapes character data, keeping newlines and quotes readable
+var xmlTextEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+func xmlAttrEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// orderedObject builds a JSON object with keys in the given order
+func orderedObject(keys []string, values map[string]string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kj, _ := json.Marshal(k)
+		vj, _ := json.Marshal(values[k])
+		buf.Write(kj)
+		buf.WriteByte(':')
+		buf.Write(vj)
+	}
+	buf.WriteByte('}')
+	return compactJSON(buf.String())
+}
+
+// compactJSON removes insignificant whitespace and HTML escaping, so values
+// can be compared no matter which encoder produced them
+func compactJSON(raw string) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(raw)); err != nil {
+		return raw
+	}
+	return string(rewriteStrings(buf.Bytes(), types.FileStyle{}))
+}
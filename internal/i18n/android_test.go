@@ -0,0 +1,150 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAndroidXML = `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- Shown on the start page -->
+    <string name="welcome">Willkommen</string>
+    <string name="quote">Sag \"Hallo\", it\'s &lt;here&gt; &amp; now\nnext</string>
+    <string name="styled">Hello <b>World</b></string>
+    <dimen name="margin">16dp</dimen>
+    <plurals name="items">
+        <item quantity="one">%d Element</item>
+        <item quantity="other">%d Elemente</item>
+    </plurals>
+    <string-array name="days">
+        <item>Mo</item>
+        <item>Di</item>
+    </string-array>
+</resources>
+`
+
+func TestLoadAndroidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "res", "values-de", "strings.xml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(testAndroidXML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Locale != "de" {
+		t.Errorf("LoadFile() Locale = %s, want de", file.Locale)
+	}
+
+	want := `{"welcome":"Willkommen","quote":"Sag \"Hallo\", it's <here> & now\nnext","styled":"Hello <b>World</b>","items":{"one":"%d Element","other":"%d Elemente"},"days":["Mo","Di"]}`
+	if file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+}
+
+func TestSaveAndroidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "strings.xml")
+	if err := os.WriteFile(path, []byte(testAndroidXML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	// Saving without changes reproduces the file
+	file.Dirty = true
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != testAndroidXML {
+		t.Errorf("unchanged save = %s, want %s", got, testAndroidXML)
+	}
+
+	file.Data = `{"welcome":"Hallo @ \"du\"","quote":"Sag \"Hallo\", it's <here> & now\nnext","styled":"Hello <b>Welt</b>","items":{"one":"ein Element","other":"%d Elemente"},"added":"@neu"}`
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <!-- Shown on the start page -->
+    <string name="welcome">Hallo @ \"du\"</string>
+    <string name="quote">Sag \"Hallo\", it\'s &lt;here&gt; &amp; now\nnext</string>
+    <string name="styled">Hello <b>Welt</b></string>
+    <dimen name="margin">16dp</dimen>
+    <plurals name="items">
+        <item quantity="one">ein Element</item>
+        <item quantity="other">%d Elemente</item>
+    </plurals>
+    <string name="added">\@neu</string>
+</resources>
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("saved file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAndroidLocalePattern(t *testing.T) {
+	tests := []struct {
+		path   string
+		locale string
+	}{
+		{"res/values-de/strings.xml", "de"},
+		{"res/values-pt-rBR/strings.xml", "pt-BR"},
+		{"res/values-b+sr+Latn/strings.xml", "sr-Latn"},
+	}
+
+	pattern := "res/values-{{language}}/strings.xml"
+	for _, tt := range tests {
+		locale, _, err := ExtractMetadataFromPath(tt.path, pattern)
+		if err != nil {
+			t.Fatalf("ExtractMetadataFromPath(%s) error = %v", tt.path, err)
+		}
+		if locale != tt.locale {
+			t.Errorf("ExtractMetadataFromPath(%s) locale = %s, want %s", tt.path, locale, tt.locale)
+		}
+		if got := ConstructPathFromMetadata(pattern, locale, ""); got != tt.path {
+			t.Errorf("ConstructPathFromMetadata(%s) = %s, want %s", locale, got, tt.path)
+		}
+		if got, _ := ParseLocaleFromPath(tt.path); !strings.EqualFold(got, tt.locale) {
+			t.Errorf("ParseLocaleFromPath(%s) = %s, want %s", tt.path, got, tt.locale)
+		}
+	}
+}
+
+func TestAndroidDefaultResources(t *testing.T) {
+	dir := t.TempDir()
+	for _, locale := range []string{"values", "values-de"} {
+		path := filepath.Join(dir, "res", locale, "strings.xml")
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(`<resources><string name="hello">Hello</string></resources>`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources, _, err := DiscoverFiles([]string{filepath.Join(dir, "res", "values*", "strings.xml")})
+	if err != nil {
+		t.Fatalf("DiscoverFiles() error = %v", err)
+	}
+
+	defer func(locale string) { AndroidDefaultLocale = locale }(AndroidDefaultLocale)
+	AndroidDefaultLocale = ""
+	if _, err := LoadAllFiles(sources); err == nil || !strings.Contains(err.Error(), "no locale qualifier") {
+		t.Errorf("LoadAllFiles() without a default locale error = %v", err)
+	}
+
+	AndroidDefaultLocale = "en"
+	files, err := LoadAllFiles(sources)
+	if err != nil {
+		t.Fatalf("LoadAllFiles() error = %v", err)
+	}
+	locales, _ := GetLocaleList(files)
+	if strings.Join(locales, ",") != "en,de" {
+		t.Errorf("LoadAllFiles() locales = %v, want [en de]", locales)
+	}
+}
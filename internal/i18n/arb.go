@@ -0,0 +1,270 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// arbFormat reads and writes Flutter Application Resource Bundles (.arb).
+// Only messages are exposed as data; "@key" metadata and global "@@" entries
+// are kept aside and written back next to their messages. "@@locale" follows
+// the locale of the file.
+type arbFormat struct{}
+
+// arbEntry is one top-level entry of an ARB file
+type arbEntry struct {
+	key string
+	raw string
+}
+
+// arbState is the format state stored on I18nFile.State for ARB files
+type arbState struct {
+	entries  []arbEntry        // All entries in file order
+	metadata map[string]string // Message key -> raw "@key" object
+	messages map[string]bool   // Message keys present when loaded
+	renames  map[string]arbRename
+}
+
+// arbRename records that a message was renamed to a key of the file, so its
+// metadata is written for the new key
+type arbRename struct {
+	from     string // Old key, if the message was renamed within the file
+	metadata string // Raw "@key" object of the old key
+}
+
+func (s *arbState) clone() any {
+	c := *s
+	c.entries = slices.Clone(s.entries)
+	c.metadata = maps.Clone(s.metadata)
+	c.messages = maps.Clone(s.messages)
+	c.renames = maps.Clone(s.renames)
+	return &c
+}
+
+// newARBState returns the state of a file that was not loaded from disk
+func newARBState() *arbState {
+	return &arbState{
+		entries:  []arbEntry{{key: "@@locale"}},
+		metadata: map[string]string{},
+		messages: map[string]bool{},
+	}
+}
+
+func (arbFormat) Name() string { return "ARB" }
+
+func (arbFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+
+	content := string(stripBOM(raw))
+	if strings.TrimSpace(content) == "" {
+		content = "{}"
+	}
+	if !gjson.Valid(content) {
+		return fmt.Errorf("content is not valid JSON")
+	}
+
+	state := &arbState{metadata: make(map[string]string), messages: make(map[string]bool)}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	var err error
+	gjson.Parse(content).ForEach(func(k, v gjson.Result) bool {
+		key := k.String()
+		state.entries = append(state.entries, arbEntry{key: key, raw: v.Raw})
+
+		switch {
+		case key == "@@locale":
+			// Files without a locale in their name (app.arb) take it from the bundle
+			if _, ok := parseLocaleTag(file.Locale); !ok && v.String() != "" {
+				file.Locale = strings.ReplaceAll(v.String(), "_", "-")
+			}
+		case strings.HasPrefix(key, "@@"):
+		case strings.HasPrefix(key, "@"):
+			state.metadata[key[1:]] = v.Raw
+		default:
+			if v.Type != gjson.String {
+				err = fmt.Errorf("message %q must be a string", key)
+				return false
+			}
+			state.messages[key] = true
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(k.Raw)
+			buf.WriteByte(':')
+			buf.WriteString(v.Raw)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	buf.WriteByte('}')
+	file.Data = buf.String()
+	file.State = state
+
+	// Like Flutter, fall back to the file name suffix (app_en_US.arb)
+	if _, ok := parseLocaleTag(file.Locale); !ok {
+		base := strings.TrimSuffix(filepath.Base(file.Path), filepath.Ext(file.Path))
+		if i := strings.Index(base, "_"); i >= 0 {
+			if tag, ok := parseLocaleTag(strings.ReplaceAll(base[i+1:], "_", "-")); ok {
+				file.Locale = tag
+			}
+		}
+	}
+	return nil
+}
+
+func (arbFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	state, _ := file.State.(*arbState)
+	if state == nil {
+		state = newARBState()
+	}
+
+	data := gjson.Parse(file.Data)
+	arbLocale := strings.ReplaceAll(file.Locale, "-", "_")
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key, raw string) {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.WriteString(raw)
+	}
+
+	seen := make(map[string]bool)
+	// renamed writes the metadata of the message a new key was renamed from
+	renamed := func(key string) {
+		r, ok := state.renames[key]
+		if !ok || state.metadata[key] != "" || r.from != "" && data.Get(gjson.Escape(r.from)).Exists() {
+			return
+		}
+		write("@"+key, r.metadata)
+	}
+	for _, e := range state.entries {
+		switch {
+		case e.key == "@@locale":
+			// Keep the spelling of the file unless the locale changed
+			if e.raw == "" || strings.ReplaceAll(gjson.Parse(e.raw).String(), "_", "-") != file.Locale {
+				raw, _ := json.Marshal(arbLocale)
+				e.raw = string(raw)
+			}
+			write(e.key, e.raw)
+		case strings.HasPrefix(e.key, "@@"):
+			write(e.key, e.raw)
+		case strings.HasPrefix(e.key, "@"):
+			// Metadata of deleted messages goes with them
+			key := e.key[1:]
+			if state.messages[key] && !data.Get(gjson.Escape(key)).Exists() {
+				continue
+			}
+			write(e.key, e.raw)
+		default:
+			v := data.Get(gjson.Escape(e.key))
+			if !v.Exists() {
+				// A message renamed within the file keeps its place
+				for key, r := range state.renames {
+					if v := data.Get(gjson.Escape(key)); r.from == e.key && v.Exists() && !seen[key] {
+						seen[key] = true
+						write(key, v.Raw)
+						renamed(key)
+					}
+				}
+				continue
+			}
+			seen[e.key] = true
+			write(e.key, v.Raw)
+		}
+	}
+
+	var err error
+	data.ForEach(func(k, v gjson.Result) bool {
+		if seen[k.String()] {
+			return true
+		}
+		if v.Type != gjson.String {
+			err = fmt.Errorf("message %q must be a string", k.String())
+			return false
+		}
+		write(k.String(), v.Raw)
+		renamed(k.String())
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+
+	return applyStyle(buf.Bytes(), fileStyle(file))
+}
+
+// renameKey records the "@key" metadata of from in source for key to in target
+func (arbFormat) renameKey(source, target *types.I18nFile, from, to string) {
+	src, _ := source.State.(*arbState)
+	if src == nil {
+		return
+	}
+	r := arbRename{metadata: src.metadata[from]}
+	if prev, ok := src.renames[from]; ok && r.metadata == "" {
+		r.metadata = prev.metadata
+	}
+	if r.metadata == "" {
+		return
+	}
+
+	dst, _ := target.State.(*arbState)
+	if dst == nil {
+		dst = newARBState()
+		target.State = dst
+	}
+	if src == dst {
+		r.from = from
+	}
+	if dst.renames == nil {
+		dst.renames = make(map[string]arbRename)
+	}
+	dst.renames[to] = r
+}
+
+// KeyDescription returns the description a file's format stores for a key,
+// such as the "description" of ARB message metadata
+func KeyDescription(file *types.I18nFile, key string) string {
+	state, ok := file.State.(*arbState)
+	if !ok {
+		return ""
+	}
+	return gjson.Get(state.metadata[key], "description").String()
+}
+
+// DeclaredPlaceholders returns the placeholders declared for a key in the
+// file's metadata (ARB "placeholders"), and whether any declaration exists
+func DeclaredPlaceholders(file *types.I18nFile, key string) ([]string, bool) {
+	state, ok := file.State.(*arbState)
+	if !ok {
+		return nil, false
+	}
+	placeholders := gjson.Get(state.metadata[key], "placeholders")
+	if !placeholders.IsObject() {
+		return nil, false
+	}
+
+	var names []string
+	placeholders.ForEach(func(k, _ gjson.Result) bool {
+		names = append(names, k.String())
+		return true
+	})
+	return names, true
+}
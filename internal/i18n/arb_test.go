@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+const testARB = `{
+  "@@locale": "en",
+  "@@last_modified": "2024-01-01",
+  "greeting": "Hello {name}",
+  "@greeting": {
+    "description": "Shown on the home screen",
+    "placeholders": {
+      "name": {
+        "type": "String"
+      }
+    }
+  },
+  "items": "{count, plural, one {# item} other {# items}}"
+}
+`
+
+func TestLoadARBFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app_en.arb")
+	if err := os.WriteFile(path, []byte(testARB), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Locale != "en" {
+		t.Errorf("LoadFile() Locale = %s, want en", file.Locale)
+	}
+
+	want := `{"greeting":"Hello {name}","items":"{count, plural, one {# item} other {# items}}"}`
+	if file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+	if got := KeyDescription(file, "greeting"); got != "Shown on the home screen" {
+		t.Errorf("KeyDescription() = %q", got)
+	}
+	if got, ok := DeclaredPlaceholders(file, "greeting"); !ok || !reflect.DeepEqual(got, []string{"name"}) {
+		t.Errorf("DeclaredPlaceholders() = %v, %v", got, ok)
+	}
+	if _, ok := DeclaredPlaceholders(file, "items"); ok {
+		t.Errorf("DeclaredPlaceholders(items) should have no declaration")
+	}
+}
+
+func TestARBLocaleFromFileName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app_pt_BR.arb")
+	if err := os.WriteFile(path, []byte(`{"hello": "Olá"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Locale != "pt-BR" {
+		t.Errorf("LoadFile() Locale = %s, want pt-BR", file.Locale)
+	}
+}
+
+func TestSaveARBFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app_en.arb")
+	if err := os.WriteFile(path, []byte(testARB), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	// Saving without changes reproduces the file
+	file.Dirty = true
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != testARB {
+		t.Errorf("unchanged save =\n%s\nwant\n%s", got, testARB)
+	}
+
+	// Deleting a message drops its metadata, new messages are appended
+	file.Data = `{"items":"{count, plural, one {# thing} other {# things}}","bye":"Bye"}`
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	want := `{
+  "@@locale": "en",
+  "@@last_modified": "2024-01-01",
+  "items": "{count, plural, one {# thing} other {# things}}",
+  "bye": "Bye"
+}
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("saved file =\n%s\nwant\n%s", got, want)
+	}
+
+	// New files get @@locale from their locale
+	newFile := &types.I18nFile{Path: filepath.Join(dir, "app_de_AT.arb"), Locale: "de-AT", Data: `{"hello":"Servus"}`}
+	if err := SaveFile(newFile); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	want = "{\n  \"@@locale\": \"de_AT\",\n  \"hello\": \"Servus\"\n}"
+	if got, _ := os.ReadFile(newFile.Path); string(got) != want {
+		t.Errorf("new file =\n%s\nwant\n%s", got, want)
+	}
+}
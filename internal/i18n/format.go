@@ -0,0 +1,183 @@
+package i18n
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// Format converts between a file's on-disk representation and the JSON data
+// held in I18nFile.Data, which is what the rest of i18nedt works on
+type Format interface {
+	// Name is a human readable name used in messages
+	Name() string
+	// Decode parses raw file content into file.Data. Anything needed to write
+	// the file back faithfully (style, comments, metadata) is kept on file.
+	Decode(file *types.I18nFile, raw []byte) error
+	// Encode renders file.Data in the format
+	Encode(file *types.I18nFile) ([]byte, error)
+}
+
+// formats maps lower-case file extensions to their format
+var formats = map[string]Format{
+	".json": jsonFormat{},
+	".yml":  yamlFormat{},
+	".yaml": yamlFormat{},
+	".po":   poFormat{},
+	".pot":  poFormat{},
+	".arb":  arbFormat{},
+
+	".xml":         androidFormat{},
+	".strings":     stringsFormat{},
+	".stringsdict": stringsdictFormat{},
+	".xcstrings":   xcstringsFormat{},
+}
+
+// multiLocaleFormat is implemented by formats that keep several locales in one
+// file. Split turns a loaded file into one view per locale; the views share the
+// path and are always saved together.
+type multiLocaleFormat interface {
+	Format
+	Split(file *types.I18nFile) []*types.I18nFile
+}
+
+// ViewID identifies a loaded file: its path, followed by "#" and the locale
+// for the per-locale views of a multi-locale file, which share the path
+func ViewID(file *types.I18nFile) string {
+	if _, ok := FormatFor(file.Path).(multiLocaleFormat); ok {
+		return file.Path + "#" + file.Locale
+	}
+	return file.Path
+}
+
+// clonableState is implemented by format states that change after loading,
+// so that copies of a file can get their own
+type clonableState interface {
+	clone() any
+}
+
+// CloneFiles copies files together with their format state, so changing the
+// copies, as a preview does, leaves files alone. Views of a multi-locale file
+// share the copy of its state.
+func CloneFiles(files []*types.I18nFile) []*types.I18nFile {
+	copies := make([]*types.I18nFile, len(files))
+	copyOf := make(map[*types.I18nFile]*types.I18nFile, len(files))
+	states := make(map[any]any)
+	for i, file := range files {
+		c := *file
+		if s, ok := file.State.(clonableState); ok {
+			if _, done := states[s]; !done {
+				states[s] = s.clone()
+			}
+			c.State = states[s]
+		}
+		copies[i] = &c
+		copyOf[file] = &c
+	}
+
+	// Catalogs save the copies of their views
+	for _, s := range states {
+		cat, ok := s.(*xcstringsCatalog)
+		if !ok {
+			continue
+		}
+		for i, view := range cat.views {
+			if c, ok := copyOf[view]; ok {
+				cat.views[i] = c
+			} else {
+				c := *view
+				c.State = cat
+				cat.views[i] = &c
+			}
+		}
+	}
+	return copies
+}
+
+// keyRenamingFormat is implemented by formats that keep metadata of a key
+// outside of its value. A rename is only recorded on the target file; the
+// metadata follows the key when it is saved under its new name.
+type keyRenamingFormat interface {
+	Format
+	renameKey(source, target *types.I18nFile, from, to string)
+}
+
+// RenameKeyMetadata lets the metadata of key from in source, such as ARB
+// "@key" entries, PO comments or xcstrings comments, follow the key when it is
+// renamed to to in target. Keys are gjson paths; only top-level keys of files
+// of the same format have metadata.
+func RenameKeyMetadata(source, target *types.I18nFile, from, to string) {
+	f, ok := FormatFor(source.Path).(keyRenamingFormat)
+	if !ok || FormatFor(target.Path) != Format(f) {
+		return
+	}
+	fromKey, ok := topLevelKey(from)
+	if !ok {
+		return
+	}
+	toKey, ok := topLevelKey(to)
+	if !ok {
+		return
+	}
+	f.renameKey(source, target, fromKey, toKey)
+}
+
+// topLevelKey returns the key named by a gjson path, if it is top-level
+func topLevelKey(path string) (string, bool) {
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
+			return "", false
+		}
+	}
+	return flatten.UnescapeKey(path), true
+}
+
+// FormatFor returns the format for a file path based on its extension.
+// Unknown extensions are treated as JSON.
+func FormatFor(path string) Format {
+	if f, ok := formats[strings.ToLower(filepath.Ext(path))]; ok {
+		return f
+	}
+	return jsonFormat{}
+}
+
+// jsonFormat reads and writes plain JSON files, keeping their formatting style
+type jsonFormat struct{}
+
+func (jsonFormat) Name() string { return "JSON" }
+
+func (jsonFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+
+	jsonStr := string(stripBOM(raw))
+	if strings.TrimSpace(jsonStr) == "" {
+		file.Data = "{}"
+		return nil
+	}
+	if !gjson.Valid(jsonStr) {
+		return fmt.Errorf("content is not valid JSON")
+	}
+	file.Data = jsonStr
+	return nil
+}
+
+func (jsonFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	// Write back in the style the file was loaded with
+	return applyStyle([]byte(file.Data), fileStyle(file))
+}
+
+// fileStyle returns the style of the file, falling back to the default for
+// files that were not loaded from disk
+func fileStyle(file *types.I18nFile) types.FileStyle {
+	if file.Style.LineEnding == "" {
+		return types.DefaultFileStyle()
+	}
+	return file.Style
+}
@@ -0,0 +1,551 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// stringsFormat reads and writes Apple .strings files ("key" = "value";).
+// Comments and unchanged entries are written back as they are, and files
+// stored as UTF-16 stay UTF-16.
+type stringsFormat struct{}
+
+// stringsEntry is one "key" = "value"; pair
+type stringsEntry struct {
+	leading string // Whitespace and comments before the entry
+	raw     string // The entry as written in the file
+	key     string
+	value   string
+}
+
+// stringsState is the format state stored on I18nFile.State for .strings files
+type stringsState struct {
+	entries  []*stringsEntry
+	trailing string // Whitespace and comments after the last entry
+	utf16    unicode.Endianness
+	isUTF16  bool
+}
+
+func (stringsFormat) Name() string { return "Apple strings" }
+
+func (stringsFormat) Decode(file *types.I18nFile, raw []byte) error {
+	state := &stringsState{}
+	file.State = state
+
+	if bytes.HasPrefix(raw, []byte{0xFF, 0xFE}) || bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		state.isUTF16 = true
+		state.utf16 = unicode.LittleEndian
+		if raw[0] == 0xFE {
+			state.utf16 = unicode.BigEndian
+		}
+		decoded, err := unicode.UTF16(state.utf16, unicode.ExpectBOM).NewDecoder().Bytes(raw)
+		if err != nil {
+			return fmt.Errorf("failed to decode UTF-16: %w", err)
+		}
+		raw = decoded
+	}
+
+	file.Style = DetectStyle(raw)
+	content := strings.ReplaceAll(string(stripBOM(raw)), "\r\n", "\n")
+
+	entries, trailing, err := parseStrings(content)
+	if err != nil {
+		return err
+	}
+	state.entries = entries
+	state.trailing = trailing
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if seen[e.key] {
+			return fmt.Errorf("duplicate key %q", e.key)
+		}
+		seen[e.key] = true
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		value, _ := json.Marshal(e.value)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	file.Data = compactJSON(buf.String())
+	return nil
+}
+
+func (stringsFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	style.FinalNewline = true
+
+	state, _ := file.State.(*stringsState)
+	if state == nil {
+		state = &stringsState{}
+	}
+
+	values := make(map[string]gjson.Result)
+	var order []string
+	gjson.Parse(file.Data).ForEach(func(k, v gjson.Result) bool {
+		values[k.String()] = v
+		order = append(order, k.String())
+		return true
+	})
+
+	var out strings.Builder
+	seen := make(map[string]bool)
+	separator := "\n"
+	for _, e := range state.entries {
+		v, ok := values[e.key]
+		if !ok {
+			continue // Deleted
+		}
+		seen[e.key] = true
+		if v.Type != gjson.String {
+			return nil, fmt.Errorf("value of key %s must be a string", e.key)
+		}
+		if strings.TrimSpace(e.leading) == "" && e.leading != "" {
+			separator = e.leading
+		}
+
+		if v.String() == e.value {
+			out.WriteString(e.leading + e.raw)
+		} else {
+			out.WriteString(e.leading + formatStringsEntry(e.key, v.String()))
+		}
+	}
+
+	for _, key := range order {
+		if seen[key] {
+			continue
+		}
+		v := values[key]
+		if v.Type != gjson.String {
+			return nil, fmt.Errorf("value of key %s must be a string", key)
+		}
+		if out.Len() > 0 {
+			out.WriteString(separator)
+		}
+		out.WriteString(formatStringsEntry(key, v.String()))
+	}
+	out.WriteString(state.trailing)
+
+	data := applyLineStyle([]byte(out.String()), style)
+	if state.isUTF16 {
+		encoded, err := unicode.UTF16(state.utf16, unicode.UseBOM).NewEncoder().Bytes(stripBOM(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode UTF-16: %w", err)
+		}
+		return encoded, nil
+	}
+	return data, nil
+}
+
+// parseStrings splits a .strings file into entries
+func parseStrings(content string) ([]*stringsEntry, string, error) {
+	var entries []*stringsEntry
+	pos := 0
+	line := func() int { return strings.Count(content[:pos], "\n") + 1 }
+
+	for {
+		start := pos
+		pos = skipStringsSpace(content, pos)
+		if pos >= len(content) {
+			return entries, content[start:], nil
+		}
+
+		entry := &stringsEntry{leading: content[start:pos]}
+		entryStart := pos
+
+		key, next, err := readStringsToken(content, pos)
+		if err != nil {
+			return nil, "", fmt.Errorf("line %d: %w", line(), err)
+		}
+		pos = skipStringsSpace(content, next)
+
+		if pos < len(content) && content[pos] == ';' {
+			// "key"; is shorthand for "key" = "key";
+			entry.key, entry.value = key, key
+		} else {
+			if pos >= len(content) || content[pos] != '=' {
+				return nil, "", fmt.Errorf("line %d: expected '=' after key %q", line(), key)
+			}
+			pos = skipStringsSpace(content, pos+1)
+			value, next, err := readStringsToken(content, pos)
+			if err != nil {
+				return nil, "", fmt.Errorf("line %d: %w", line(), err)
+			}
+			pos = skipStringsSpace(content, next)
+			if pos >= len(content) || content[pos] != ';' {
+				return nil, "", fmt.Errorf("line %d: expected ';' after value of key %q", line(), key)
+			}
+			entry.key, entry.value = key, value
+		}
+
+		pos++
+		entry.raw = content[entryStart:pos]
+		entries = append(entries, entry)
+	}
+}
+
+// skipStringsSpace skips whitespace and comments
+func skipStringsSpace(content string, pos int) int {
+	for pos < len(content) {
+		switch {
+		case content[pos] == ' ' || content[pos] == '\t' || content[pos] == '\n' || content[pos] == '\r':
+			pos++
+		case strings.HasPrefix(content[pos:], "//"):
+			end := strings.IndexByte(content[pos:], '\n')
+			if end < 0 {
+				return len(content)
+			}
+			pos += end + 1
+		case strings.HasPrefix(content[pos:], "/*"):
+			end := strings.Index(content[pos+2:], "*/")
+			if end < 0 {
+				return len(content)
+			}
+			pos += end + 4
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// readStringsToken reads a quoted string or an unquoted word
+func readStringsToken(content string, pos int) (string, int, error) {
+	if pos >= len(content) {
+		return "", pos, fmt.Errorf("unexpected end of file")
+	}
+
+	if content[pos] != '"' {
+		end := pos
+		for end < len(content) && isStringsWordChar(content[end]) {
+			end++
+		}
+		if end == pos {
+			return "", pos, fmt.Errorf("unexpected character %q", content[pos])
+		}
+		return content[pos:end], end, nil
+	}
+
+	var b strings.Builder
+	for i := pos + 1; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '"':
+			return b.String(), i + 1, nil
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch content[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			case 'u', 'U':
+				if i+5 > len(content) {
+					return "", i, fmt.Errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(content[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", i, fmt.Errorf("invalid unicode escape: %w", err)
+				}
+				r := rune(code)
+				i += 4
+				// Characters outside the BMP are written as surrogate pairs
+				rest := content[i+1:]
+				if r >= 0xD800 && r < 0xDC00 && len(rest) >= 6 && (strings.HasPrefix(rest, `\U`) || strings.HasPrefix(rest, `\u`)) {
+					if low, err := strconv.ParseUint(rest[2:6], 16, 32); err == nil {
+						r = 0x10000 + (r-0xD800)<<10 + (rune(low) - 0xDC00)
+						i += 6
+					}
+				}
+				b.WriteRune(r)
+			default:
+				b.WriteByte(content[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", len(content), fmt.Errorf("unterminated string")
+}
+
+func isStringsWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-' || c == '$' || c == ':' || c == '/'
+}
+
+// formatStringsEntry renders a "key" = "value"; line
+func formatStringsEntry(key, value string) string {
+	return quoteStrings(key) + " = " + quoteStrings(value) + ";"
+}
+
+func quoteStrings(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\U%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// stringsdictFormat reads and writes Apple .stringsdict property lists. Each
+// top-level key maps to an object mirroring its plist dictionary, so plural
+// rules show up as JSON values in the editor.
+type stringsdictFormat struct{}
+
+// stringsdictState is the format state stored on I18nFile.State for
+// .stringsdict files
+type stringsdictState struct {
+	header string // Everything before the root <dict>
+}
+
+const plistHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+func (stringsdictFormat) Name() string { return "stringsdict" }
+
+func (stringsdictFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+	content := strings.ReplaceAll(string(stripBOM(raw)), "\r\n", "\n")
+
+	state := &stringsdictState{header: plistHeader}
+	file.State = state
+	if strings.TrimSpace(content) == "" {
+		file.Data = "{}"
+		return nil
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(content))
+	for {
+		offset := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return fmt.Errorf("missing root <dict>")
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+		if start.Name.Local != "dict" {
+			return fmt.Errorf("root element must be <dict>, got <%s>", start.Name.Local)
+		}
+
+		state.header = content[:offset]
+		var buf bytes.Buffer
+		if err := writePlistJSON(&buf, dec, start); err != nil {
+			return err
+		}
+		file.Data = compactJSON(buf.String())
+		return nil
+	}
+}
+
+func (stringsdictFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	style.FinalNewline = true
+
+	header := plistHeader
+	if state, ok := file.State.(*stringsdictState); ok {
+		header = state.header
+	}
+	// Keep the header's trailing newline out of the indentation of <dict>
+	header = strings.TrimRight(header, " \t")
+
+	var out strings.Builder
+	out.WriteString(header)
+	if err := writePlistValue(&out, gjson.Parse(file.Data), 0); err != nil {
+		return nil, err
+	}
+	out.WriteString("\n</plist>\n")
+	return applyLineStyle([]byte(out.String()), style), nil
+}
+
+// writePlistJSON converts the plist element started by start into JSON
+func writePlistJSON(buf *bytes.Buffer, dec *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "dict":
+		buf.WriteByte('{')
+		first := true
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				buf.WriteByte('}')
+				return nil
+			case xml.StartElement:
+				if t.Name.Local != "key" {
+					return fmt.Errorf("expected <key> in <dict>, got <%s>", t.Name.Local)
+				}
+				var key string
+				if err := dec.DecodeElement(&key, &t); err != nil {
+					return err
+				}
+				value, err := nextPlistElement(dec)
+				if err != nil {
+					return err
+				}
+				if !first {
+					buf.WriteByte(',')
+				}
+				first = false
+				k, _ := json.Marshal(key)
+				buf.Write(k)
+				buf.WriteByte(':')
+				if err := writePlistJSON(buf, dec, value); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "array":
+		buf.WriteByte('[')
+		first := true
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				buf.WriteByte(']')
+				return nil
+			case xml.StartElement:
+				if !first {
+					buf.WriteByte(',')
+				}
+				first = false
+				if err := writePlistJSON(buf, dec, t); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "string", "integer", "real":
+		var s string
+		if err := dec.DecodeElement(&s, &start); err != nil {
+			return err
+		}
+		if start.Name.Local == "string" {
+			v, _ := json.Marshal(s)
+			buf.Write(v)
+			return nil
+		}
+		if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			return fmt.Errorf("invalid <%s> %q", start.Name.Local, s)
+		}
+		buf.WriteString(strings.TrimSpace(s))
+		return nil
+
+	case "true", "false":
+		buf.WriteString(start.Name.Local)
+		return dec.Skip()
+	}
+
+	return fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+}
+
+// nextPlistElement returns the next start element, skipping whitespace and comments
+func nextPlistElement(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			return t, nil
+		case xml.EndElement:
+			return xml.StartElement{}, fmt.Errorf("missing value for key")
+		}
+	}
+}
+
+// writePlistValue renders a JSON value as plist XML, indented with tabs like Xcode
+func writePlistValue(out *strings.Builder, v gjson.Result, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	switch {
+	case v.IsObject():
+		out.WriteString(indent + "<dict>\n")
+		var err error
+		v.ForEach(func(k, item gjson.Result) bool {
+			out.WriteString(indent + "\t<key>" + xmlAttrEscape(k.String()) + "</key>\n")
+			if err = writePlistValue(out, item, depth+1); err != nil {
+				return false
+			}
+			out.WriteByte('\n')
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		out.WriteString(indent + "</dict>")
+	case v.IsArray():
+		out.WriteString(indent + "<array>\n")
+		for _, item := range v.Array() {
+			if err := writePlistValue(out, item, depth+1); err != nil {
+				return err
+			}
+			out.WriteByte('\n')
+		}
+		out.WriteString(indent + "</array>")
+	case v.Type == gjson.String:
+		out.WriteString(indent + "<string>" + xmlTextEscape(v.String()) + "</string>")
+	case v.Type == gjson.Number:
+		if strings.ContainsAny(v.Raw, ".eE") {
+			out.WriteString(indent + "<real>" + v.Raw + "</real>")
+		} else {
+			out.WriteString(indent + "<integer>" + v.Raw + "</integer>")
+		}
+	case v.Type == gjson.True:
+		out.WriteString(indent + "<true/>")
+	case v.Type == gjson.False:
+		out.WriteString(indent + "<false/>")
+	default:
+		return fmt.Errorf("null values cannot be written to a property list")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/unicode"
+)
+
+const testStrings = `/* Shown on the start page */
+"welcome" = "Willkommen";
+
+// Quotes and escapes
+"quote" = "Sag \"Hallo\"\nnext";
+settings.title = "Einstellungen";
+`
+
+func TestLoadStringsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "de.lproj", "Localizable.strings")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(testStrings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if file.Locale != "de" {
+		t.Errorf("LoadFile() Locale = %s, want de", file.Locale)
+	}
+
+	want := `{"welcome":"Willkommen","quote":"Sag \"Hallo\"\nnext","settings.title":"Einstellungen"}`
+	if file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+}
+
+func TestSaveStringsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Localizable.strings")
+	if err := os.WriteFile(path, []byte(testStrings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	file.Dirty = true
+	file.Data = `{"welcome":"Hallo","settings.title":"Einstellungen","added":"Neu \\ \"x\""}`
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	want := `/* Shown on the start page */
+"welcome" = "Hallo";
+settings.title = "Einstellungen";
+"added" = "Neu \\ \"x\"";
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("saved file =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestStringsFileUTF16(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Localizable.strings")
+	encoder := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder()
+	raw, _ := encoder.Bytes([]byte("\"hello\" = \"Grüß dich\";\n"))
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if want := `{"hello":"Grüß dich"}`; file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+
+	file.Dirty = true
+	file.Data = `{"hello":"Servus"}`
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	want, _ := encoder.Bytes([]byte("\"hello\" = \"Servus\";\n"))
+	if got, _ := os.ReadFile(path); string(got) != string(want) {
+		t.Errorf("saved file = %q, want %q", got, want)
+	}
+}
+
+func TestParseStringsErrors(t *testing.T) {
+	tests := []string{
+		`"a" = "b"`,
+		`"a" "b";`,
+		`"a" = "b;`,
+	}
+	for _, content := range tests {
+		if _, _, err := parseStrings(content); err == nil {
+			t.Errorf("parseStrings(%q) expected error", content)
+		}
+	}
+}
+
+const testStringsdict = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>items</key>
+	<dict>
+		<key>NSStringLocalizedFormatKey</key>
+		<string>%#@items@</string>
+		<key>items</key>
+		<dict>
+			<key>NSStringFormatSpecTypeKey</key>
+			<string>NSStringPluralRuleType</string>
+			<key>NSStringFormatValueTypeKey</key>
+			<string>d</string>
+			<key>one</key>
+			<string>%d Element</string>
+			<key>other</key>
+			<string>%d Elemente</string>
+		</dict>
+	</dict>
+</dict>
+</plist>
+`
+
+func TestStringsdictRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "de.lproj", "Localizable.stringsdict")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(testStringsdict), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := `{"items":{"NSStringLocalizedFormatKey":"%#@items@","items":{"NSStringFormatSpecTypeKey":"NSStringPluralRuleType","NSStringFormatValueTypeKey":"d","one":"%d Element","other":"%d Elemente"}}}`
+	if file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+
+	file.Dirty = true
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != testStringsdict {
+		t.Errorf("saved file =\n%s\nwant\n%s", got, testStringsdict)
+	}
+}
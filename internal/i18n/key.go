@@ -42,6 +42,30 @@ func SetValue(jsonStr, key, value string) (string, error) {
 	return newJson, nil
 }
 
+// GetRawValue retrieves the raw JSON of a key, reporting whether it exists
+func GetRawValue(jsonStr, key string) (string, bool, error) {
+	if !gjson.Valid(jsonStr) {
+		return "", false, fmt.Errorf("invalid JSON string")
+	}
+
+	result := gjson.Get(jsonStr, key)
+	return result.Raw, result.Exists(), nil
+}
+
+// SetRawValue sets a key to raw JSON, keeping numbers, booleans and objects as they are
+func SetRawValue(jsonStr, key, raw string) (string, error) {
+	if !gjson.Valid(jsonStr) {
+		return "", fmt.Errorf("invalid JSON string")
+	}
+
+	newJson, err := sjson.SetRaw(jsonStr, key, raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to set key '%s': %w", key, err)
+	}
+
+	return newJson, nil
+}
+
 // DeleteValue removes a key from JSON string using sjson
 func DeleteValue(jsonStr, key string) (string, error) {
 	if !gjson.Valid(jsonStr) {
@@ -1,6 +1,8 @@
 package i18n
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -23,6 +25,13 @@ func extractBCP47TagStrict(filePath string) (string, bool) {
 			continue
 		}
 
+		// Android 资源目录 (values-de, values-pt-rBR)
+		if strings.HasPrefix(pathPart, "values-") {
+			if tag, ok := parseLocaleTag(androidQualifierToLocale(pathPart[len("values-"):])); ok {
+				return tag, true
+			}
+		}
+
 		// 对每个路径部分，再按点号分割，也反向遍历
 		subParts := strings.Split(pathPart, ".")
 		for j := len(subParts) - 1; j >= 0; j-- {
@@ -31,16 +40,9 @@ func extractBCP47TagStrict(filePath string) (string, bool) {
 				continue
 			}
 
-			tag, err := language.Parse(subPart)
-			if err == nil {
-				// 验证是否为有效的语言标签（非任意字符串）
-				region, conf := tag.Region()
-				// 只有当地区不是未知地区(ZZ)并且置信度不是No时才接受
-				// 这确保我们不会把像"app"这样的任意字符串当作语言标签
-				if region.String() != "ZZ" && conf != language.No {
-					// 找到有效的语言标签
-					return tag.String(), true
-				}
+			if tag, ok := parseLocaleTag(subPart); ok {
+				// 找到有效的语言标签
+				return tag, true
 			}
 		}
 	}
@@ -48,8 +50,36 @@ func extractBCP47TagStrict(filePath string) (string, bool) {
 	return "", false
 }
 
+// parseLocaleTag parses s as a BCP47 language tag
+func parseLocaleTag(s string) (string, bool) {
+	tag, err := language.Parse(s)
+	if err != nil {
+		return "", false
+	}
+
+	// 验证是否为有效的语言标签（非任意字符串）
+	region, conf := tag.Region()
+	// 只有当地区不是未知地区(ZZ)并且置信度不是No时才接受
+	// 这确保我们不会把像"app"这样的任意字符串当作语言标签
+	if region.String() != "ZZ" && conf != language.No {
+		return tag.String(), true
+	}
+	return "", false
+}
+
+// AndroidDefaultLocale is the locale of Android default resources, which
+// live in a values/ directory without locale qualifier
+var AndroidDefaultLocale = os.Getenv("I18NEDT_SOURCE_LOCALE")
+
 // ParseLocaleFromPath extracts locale code from file path using BCP47
 func ParseLocaleFromPath(filePath string) (string, error) {
+	if _, ok := FormatFor(filePath).(androidFormat); ok && filepath.Base(filepath.Dir(filePath)) == "values" {
+		if AndroidDefaultLocale == "" {
+			return "", fmt.Errorf("Android default resources have no locale qualifier, set their locale with --source-locale or I18NEDT_SOURCE_LOCALE")
+		}
+		return AndroidDefaultLocale, nil
+	}
+
 	if bcp47Tag, found := extractBCP47TagStrict(filePath); found {
 		return bcp47Tag, nil
 	}
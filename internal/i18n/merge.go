@@ -0,0 +1,126 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/tidwall/sjson"
+)
+
+// MergeConflict describes a key changed differently on disk and in the editor.
+// Values are raw JSON; an empty string means the key does not exist on that side.
+type MergeConflict struct {
+	Key    string
+	Base   string
+	Theirs string
+	Ours   string
+}
+
+// ConflictResolver decides a merge conflict for the given file.
+// It returns true to keep our (edited) value, false to keep the value on disk.
+type ConflictResolver func(file string, conflict MergeConflict) bool
+
+// MergeThreeWay merges our changes to base into theirs at leaf-key level.
+// Keys changed only on one side are taken from that side; keys changed
+// differently on both sides are returned as conflicts and left as in theirs.
+func MergeThreeWay(base, theirs, ours string) (string, []MergeConflict, error) {
+	baseFlat, err := flatten.FlattenJSON([]byte(base), "", "")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to flatten base: %w", err)
+	}
+	theirsFlat, err := flatten.FlattenJSON([]byte(theirs), "", "")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to flatten file on disk: %w", err)
+	}
+	oursFlat, err := flatten.FlattenJSON([]byte(ours), "", "")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to flatten edited data: %w", err)
+	}
+
+	// Collect all keys for a stable, sorted walk
+	keySet := make(map[string]bool)
+	for _, flat := range []map[string]string{baseFlat, theirsFlat, oursFlat} {
+		for k := range flat {
+			keySet[k] = true
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var deletes []string
+	sets := make(map[string]string)
+	var conflicts []MergeConflict
+
+	for _, k := range keys {
+		b, t, o := baseFlat[k], theirsFlat[k], oursFlat[k]
+
+		switch {
+		case o == b || o == t:
+			// We didn't change it, or both sides agree: theirs already has it
+		case t == b:
+			// Only we changed it
+			if o == "" {
+				deletes = append(deletes, k)
+			} else {
+				sets[k] = o
+			}
+		default:
+			conflicts = append(conflicts, MergeConflict{Key: k, Base: b, Theirs: t, Ours: o})
+		}
+	}
+
+	merged, err := applyFlatChanges(theirs, deletes, sets)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return merged, conflicts, nil
+}
+
+// applyFlatChanges deletes and sets flattened leaf keys on a JSON document.
+// Deletions run first so a leaf can replace a removed object.
+func applyFlatChanges(data string, deletes []string, sets map[string]string) (string, error) {
+	var err error
+	for _, k := range deletes {
+		if data, err = sjson.Delete(data, k); err != nil {
+			return "", fmt.Errorf("failed to delete key '%s': %w", k, err)
+		}
+	}
+
+	keys := make([]string, 0, len(sets))
+	for k := range sets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if data, err = sjson.SetRaw(data, k, sets[k]); err != nil {
+			return "", fmt.Errorf("failed to set key '%s': %w", k, err)
+		}
+	}
+
+	return data, nil
+}
+
+// resolveConflicts applies conflicts the resolver decided in our favour
+func resolveConflicts(file, merged string, conflicts []MergeConflict, resolve ConflictResolver) (string, error) {
+	var deletes []string
+	sets := make(map[string]string)
+
+	for _, c := range conflicts {
+		if !resolve(file, c) {
+			continue
+		}
+		if c.Ours == "" {
+			deletes = append(deletes, c.Key)
+		} else {
+			sets[c.Key] = c.Ours
+		}
+	}
+
+	return applyFlatChanges(merged, deletes, sets)
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestMergeThreeWay(t *testing.T) {
+	base := `{"a": "1", "b": "2", "c": "3", "d": "4"}`
+	theirs := `{"a": "1", "b": "two", "c": "3", "d": "four", "e": "5"}`
+	ours := `{"a": "one", "b": "2", "d": "FOUR"}`
+
+	merged, conflicts, err := MergeThreeWay(base, theirs, ours)
+	if err != nil {
+		t.Fatalf("MergeThreeWay() error = %v", err)
+	}
+
+	want := map[string]string{
+		"a": "one",  // changed only by us
+		"b": "two",  // changed only on disk
+		"e": "5",    // added on disk
+		"d": "four", // conflict keeps theirs until resolved
+	}
+	for k, v := range want {
+		if got := gjson.Get(merged, k).String(); got != v {
+			t.Errorf("MergeThreeWay() %s = %q, want %q", k, got, v)
+		}
+	}
+	if gjson.Get(merged, "c").Exists() {
+		t.Error("MergeThreeWay() key deleted by us should be removed")
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Key != "d" {
+		t.Fatalf("MergeThreeWay() conflicts = %v, want only d", conflicts)
+	}
+	if conflicts[0].Theirs != `"four"` || conflicts[0].Ours != `"FOUR"` {
+		t.Errorf("MergeThreeWay() conflict values = %+v", conflicts[0])
+	}
+}
+
+func TestSaveFileConcurrentModification(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "en-US.json")
+	if err := os.WriteFile(path, []byte(`{"a": "1", "b": "2"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	// Someone else changes the file while we are editing
+	if err := os.WriteFile(path, []byte(`{"a": "1", "b": "two", "c": "3"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file.Data = `{"a": "one", "b": "2"}`
+	file.Dirty = true
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	saved, _ := os.ReadFile(path)
+	for k, v := range map[string]string{"a": "one", "b": "two", "c": "3"} {
+		if got := gjson.GetBytes(saved, k).String(); got != v {
+			t.Errorf("SaveFile() %s = %q, want %q", k, got, v)
+		}
+	}
+
+	// A conflicting change without a resolver fails the save
+	if err := os.WriteFile(path, []byte(`{"a": "uno", "b": "two", "c": "3"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file.Data = `{"a": "ONE", "b": "two", "c": "3"}`
+	if err := SaveFile(file); err == nil {
+		t.Error("SaveFile() expected conflict error")
+	}
+
+	// With a resolver our value wins
+	keepOurs := func(string, MergeConflict) bool { return true }
+	if err := SaveFileWithResolver(file, keepOurs); err != nil {
+		t.Fatalf("SaveFileWithResolver() error = %v", err)
+	}
+	saved, _ = os.ReadFile(path)
+	if got := gjson.GetBytes(saved, "a").String(); got != "ONE" {
+		t.Errorf("SaveFileWithResolver() a = %q, want ONE", got)
+	}
+}
@@ -62,10 +62,14 @@ func CreateMissingNamespaces(files []*types.I18nFile, sources []types.FileSource
 				Locale:    loc,
 				Namespace: ns,
 				Dirty:     false, // Will be set to true if edited later
+				Original:  "{}",
+				Hash:      ContentHash(nil),
+				Style:     types.DefaultFileStyle(),
 			}
 			files = append(files, newFile)
 		}
 	}
+	adoptLocaleRoots(files)
 
 	return files, createdNs, nil
 }
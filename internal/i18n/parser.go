@@ -1,17 +1,18 @@
 package i18n
 
 import (
-	"bytes"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/kikyous/i18nedt/pkg/types"
 	"github.com/tidwall/gjson"
 )
 
-// LoadFile loads and parses an i18n JSON file
+// LoadFile loads and parses an i18n file in any supported format
 func LoadFile(filePath string, pattern string) (*types.I18nFile, error) {
 	// Determine locale and namespace
 	var locale, namespace string
@@ -37,6 +38,9 @@ func LoadFile(filePath string, pattern string) (*types.I18nFile, error) {
 		Data:      "{}", // Default empty JSON object
 		Locale:    locale,
 		Namespace: namespace,
+		Original:  "{}",
+		Hash:      ContentHash(nil),
+		Style:     types.DefaultFileStyle(),
 	}
 
 	// Check if file exists
@@ -51,67 +55,129 @@ func LoadFile(filePath string, pattern string) (*types.I18nFile, error) {
 		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	// Validate JSON content
-	jsonStr := string(data)
-	if jsonStr == "" {
-		file.Data = "{}"
-	} else if !gjson.Valid(jsonStr) {
-		return nil, fmt.Errorf("invalid JSON in file %s", filePath)
-	} else {
-		file.Data = jsonStr
+	// Decode content into JSON data
+	format := FormatFor(filePath)
+	if err := format.Decode(file, data); err != nil {
+		return nil, fmt.Errorf("invalid %s in file %s: %w", format.Name(), filePath, err)
 	}
+	file.Original = file.Data
+	file.Hash = ContentHash(data)
 
 	return file, nil
 }
 
+// ContentHash returns the hash used to detect changes of a file on disk
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // SaveFile saves an i18n file to disk
 func SaveFile(file *types.I18nFile) error {
+	return SaveFileWithResolver(file, nil)
+}
+
+// SaveFileWithResolver saves an i18n file to disk. If the file changed on disk
+// since it was loaded, the edits are merged into the new content key by key and
+// resolve is asked about keys changed on both sides. Without a resolver such
+// conflicts fail the save.
+func SaveFileWithResolver(file *types.I18nFile, resolve ConflictResolver) error {
+	return commitFiles([]*types.I18nFile{file}, resolve)
+}
+
+// formatFile renders the file data the way it is written to disk
+func formatFile(file *types.I18nFile) ([]byte, error) {
 	// Ensure JSON is valid
 	if !gjson.Valid(file.Data) {
-		return fmt.Errorf("invalid JSON data for file %s", file.Path)
+		return nil, fmt.Errorf("invalid JSON data for file %s", file.Path)
+	}
+
+	return FormatFor(file.Path).Encode(file)
+}
+
+// mergeConcurrentChanges merges file.Data with the content on disk if the file
+// was modified by someone else after it was loaded
+func mergeConcurrentChanges(file *types.I18nFile, resolve ConflictResolver) error {
+	if file.Hash == "" {
+		// Not loaded from disk, nothing to compare against
+		return nil
+	}
+
+	current, err := os.ReadFile(file.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file %s: %w", file.Path, err)
+	}
+	if ContentHash(current) == file.Hash {
+		return nil
 	}
 
-	// Ensure directory exists
-	dir := GetDirectory(file.Path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	// Decode the new content without touching the state of our file
+	onDisk := &types.I18nFile{Path: file.Path, Locale: file.Locale, Namespace: file.Namespace}
+	if err := FormatFor(file.Path).Decode(onDisk, current); err != nil {
+		return fmt.Errorf("file %s was changed on disk and can no longer be parsed: %w", file.Path, err)
 	}
+	theirs := onDisk.Data
 
-	// Format JSON with proper indentation
-	var formatted bytes.Buffer
-	if err := json.Indent(&formatted, []byte(file.Data), "", "  "); err != nil {
-		return fmt.Errorf("failed to format JSON: %w", err)
+	base := file.Original
+	if base == "" {
+		base = "{}"
 	}
 
-	// Write to temporary file first
-	tempFile := file.Path + ".tmp"
-	if err := os.WriteFile(tempFile, formatted.Bytes(), 0644); err != nil {
-		return fmt.Errorf("failed to write temporary file %s: %w", tempFile, err)
+	merged, conflicts, err := MergeThreeWay(base, theirs, file.Data)
+	if err != nil {
+		return fmt.Errorf("failed to merge changes into %s: %w", file.Path, err)
 	}
 
-	// Rename to final file (atomic operation)
-	if err := os.Rename(tempFile, file.Path); err != nil {
-		// Clean up temp file if rename fails
-		os.Remove(tempFile)
-		return fmt.Errorf("failed to rename temporary file to %s: %w", file.Path, err)
+	if len(conflicts) > 0 {
+		if resolve == nil {
+			keys := make([]string, len(conflicts))
+			for i, c := range conflicts {
+				keys[i] = c.Key
+			}
+			return fmt.Errorf("file %s was changed on disk, conflicting keys: %s", file.Path, strings.Join(keys, ", "))
+		}
+		if merged, err = resolveConflicts(file.Path, merged, conflicts, resolve); err != nil {
+			return fmt.Errorf("failed to merge changes into %s: %w", file.Path, err)
+		}
 	}
 
+	file.Data = merged
 	return nil
 }
 
 // SaveAllFiles saves multiple i18n files
 func SaveAllFiles(files []*types.I18nFile) (int, error) {
-	count := 0
+	return SaveAllFilesWithResolver(files, nil)
+}
+
+// SaveAllFilesWithResolver saves multiple i18n files, resolving conflicts with
+// concurrent changes on disk through resolve. Dirty files are saved all or
+// nothing: if any file fails, none of them is changed.
+func SaveAllFilesWithResolver(files []*types.I18nFile, resolve ConflictResolver) (int, error) {
+	// Only save files that are dirty (modified). Locale views sharing a path
+	// with a dirty file are written together with it.
+	dirtyPaths := make(map[string]bool)
 	for _, file := range files {
-		// Only save if file is dirty (modified)
 		if file.Dirty {
-			if err := SaveFile(file); err != nil {
-				return count, err
-			}
-			count++
+			dirtyPaths[file.Path] = true
+		}
+	}
+
+	var dirty []*types.I18nFile
+	for _, file := range files {
+		if dirtyPaths[file.Path] {
+			dirty = append(dirty, file)
 		}
 	}
-	return count, nil
+
+	if len(dirty) == 0 {
+		return 0, nil
+	}
+
+	if err := commitFiles(dirty, resolve); err != nil {
+		return 0, err
+	}
+	return len(dirtyPaths), nil
 }
 
 // GetDirectory returns the directory part of a file path
@@ -128,8 +194,13 @@ func LoadAllFiles(sources []types.FileSource) ([]*types.I18nFile, error) {
 		if err != nil {
 			return nil, err
 		}
+		if f, ok := FormatFor(src.Path).(multiLocaleFormat); ok {
+			files = append(files, f.Split(file)...)
+			continue
+		}
 		files = append(files, file)
 	}
+	adoptLocaleRoots(files)
 
 	return files, nil
 }
@@ -79,11 +79,20 @@ func ExtractMetadataFromPath(path, pattern string) (string, string, error) {
 		}
 	}
 
-	return result["locale"], result["namespace"], nil
+	locale := result["locale"]
+	if isAndroidValuesPattern(pattern) {
+		locale = androidQualifierToLocale(locale)
+	}
+
+	return locale, result["namespace"], nil
 }
 
 // ConstructPathFromMetadata constructs a file path from a pattern and metadata
 func ConstructPathFromMetadata(pattern, locale, namespace string) string {
+	if isAndroidValuesPattern(pattern) {
+		locale = localeToAndroidQualifier(locale)
+	}
+
 	path := pattern
 	path = strings.ReplaceAll(path, "{{language}}", locale)
 	path = strings.ReplaceAll(path, "{{locale}}", locale)
@@ -0,0 +1,510 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// PoContextSeparator joins msgctxt and msgid into a single key ("menu|Open")
+const PoContextSeparator = "|"
+
+// poFormat reads and writes GNU gettext catalogs (.po/.pot). Every message is a
+// top-level key named after its msgid (prefixed with its msgctxt, if any) and
+// plural messages hold an array of their msgstr[n] values. Comments,
+// references, flags, the header and obsolete entries are kept as written.
+type poFormat struct{}
+
+// poEntry is one message of a catalog
+type poEntry struct {
+	head     []string // Raw comment, msgctxt, msgid and msgid_plural lines
+	msgstr   []string // Raw msgstr lines
+	key      string   // Empty for the header and obsolete entries
+	plural   bool
+	values   []string // msgstr values as loaded
+	fuzzy    bool
+	obsolete bool
+}
+
+// poState is the format state stored on I18nFile.State for PO files
+type poState struct {
+	entries []*poEntry
+	renames map[string]poRename
+}
+
+// clone copies the state; entries are not changed after loading
+func (s *poState) clone() any {
+	return &poState{entries: slices.Clone(s.entries), renames: maps.Clone(s.renames)}
+}
+
+// poRename records that a message was renamed to a key of the catalog, so it
+// keeps its comments and msgid_plural under the new key
+type poRename struct {
+	from  string // Old key, if the message was renamed within the catalog
+	entry *poEntry
+}
+
+func (poFormat) Name() string { return "PO" }
+
+func (poFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+
+	entries, err := parsePO(string(stripBOM(raw)))
+	if err != nil {
+		return err
+	}
+	file.State = &poState{entries: entries}
+
+	// Build JSON data in catalog order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.key == "" {
+			continue
+		}
+		if seen[e.key] {
+			return fmt.Errorf("duplicate message %q", e.key)
+		}
+		seen[e.key] = true
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		var value []byte
+		if e.plural {
+			value, _ = json.Marshal(e.values)
+		} else {
+			value, _ = json.Marshal(e.values[0])
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	file.Data = buf.String()
+	return nil
+}
+
+func (poFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	style.FinalNewline = true
+
+	state, _ := file.State.(*poState)
+	if state == nil {
+		state = &poState{}
+	}
+
+	// Collect values by key, keeping the order of the data for new messages
+	values := make(map[string]gjson.Result)
+	var order []string
+	gjson.Parse(file.Data).ForEach(func(k, v gjson.Result) bool {
+		values[k.String()] = v
+		order = append(order, k.String())
+		return true
+	})
+
+	var blocks []string
+	seen := make(map[string]bool)
+
+	// write adds the message key with the head and msgstr of entry e
+	write := func(e *poEntry, head []string, key string) error {
+		seen[key] = true
+		strs, err := poValues(key, values[key], e.plural)
+		if err != nil {
+			return err
+		}
+
+		msgstr := e.msgstr
+		if !equalStrings(strs, e.values) {
+			msgstr = formatMsgstr(strs, e.plural)
+		}
+		blocks = append(blocks, strings.Join(append(append([]string{}, head...), msgstr...), "\n"))
+		return nil
+	}
+	// renamed returns the entry a message was renamed from, if it still applies
+	renamed := func(key string) (*poEntry, bool) {
+		r, ok := state.renames[key]
+		if !ok || seen[key] || r.entry.plural != values[key].IsArray() {
+			return nil, false
+		}
+		if _, exists := values[r.from]; r.from != "" && exists {
+			return nil, false
+		}
+		return r.entry, true
+	}
+
+	for _, e := range state.entries {
+		if e.key == "" {
+			blocks = append(blocks, strings.Join(append(e.head, e.msgstr...), "\n"))
+			continue
+		}
+
+		if _, ok := values[e.key]; !ok {
+			// A message renamed within the catalog keeps its place
+			for key, r := range state.renames {
+				if _, ok := values[key]; ok && r.from == e.key {
+					if _, ok := renamed(key); ok {
+						if err := write(e, renamedHead(e, key), key); err != nil {
+							return nil, err
+						}
+					}
+				}
+			}
+			continue // Deleted
+		}
+		if err := write(e, e.head, e.key); err != nil {
+			return nil, err
+		}
+	}
+
+	// Append messages that were added
+	for _, key := range order {
+		if seen[key] {
+			continue
+		}
+		if e, ok := renamed(key); ok {
+			if err := write(e, renamedHead(e, key), key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		v := values[key]
+		plural := v.IsArray()
+		strs, err := poValues(key, v, plural)
+		if err != nil {
+			return nil, err
+		}
+
+		lines := append(poHead(key, plural), formatMsgstr(strs, plural)...)
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return applyLineStyle([]byte(strings.Join(blocks, "\n\n")), style), nil
+}
+
+// renameKey records the entry of from in source, with its comments, for key
+// to in target
+func (poFormat) renameKey(source, target *types.I18nFile, from, to string) {
+	src, _ := source.State.(*poState)
+	if src == nil {
+		return
+	}
+	var entry *poEntry
+	for _, e := range src.entries {
+		if e.key == from {
+			entry = e
+		}
+	}
+	if r, ok := src.renames[from]; ok && entry == nil {
+		entry = r.entry
+	}
+	if entry == nil {
+		return
+	}
+
+	dst, _ := target.State.(*poState)
+	if dst == nil {
+		dst = &poState{}
+		target.State = dst
+	}
+	r := poRename{entry: entry}
+	if src == dst {
+		r.from = from
+	}
+	if dst.renames == nil {
+		dst.renames = make(map[string]poRename)
+	}
+	dst.renames[to] = r
+}
+
+// poHead writes the msgctxt, msgid and msgid_plural lines of a new message
+func poHead(key string, plural bool) []string {
+	var lines []string
+	msgid := key
+	if i := strings.Index(key, PoContextSeparator); i >= 0 {
+		lines = append(lines, formatPOString("msgctxt", key[:i])...)
+		msgid = key[i+len(PoContextSeparator):]
+	}
+	lines = append(lines, formatPOString("msgid", msgid)...)
+	if plural {
+		lines = append(lines, formatPOString("msgid_plural", msgid)...)
+	}
+	return lines
+}
+
+// renamedHead returns the head of entry e for key: its comments and
+// msgid_plural with the msgctxt and msgid of key
+func renamedHead(e *poEntry, key string) []string {
+	var comments, plural []string
+	inPlural := false
+	for _, line := range e.head {
+		t := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(t, "#"):
+			comments = append(comments, line)
+		case strings.HasPrefix(t, "\""):
+			if inPlural {
+				plural = append(plural, line)
+			}
+		default:
+			inPlural = strings.HasPrefix(t, "msgid_plural")
+			if inPlural {
+				plural = append(plural, line)
+			}
+		}
+	}
+	return append(append(comments, poHead(key, false)...), plural...)
+}
+
+// FuzzyKeys returns the keys of a file that are flagged fuzzy, for formats
+// that support the flag
+func FuzzyKeys(file *types.I18nFile) []string {
+	state, ok := file.State.(*poState)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, e := range state.entries {
+		if e.fuzzy && e.key != "" {
+			keys = append(keys, e.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parsePO splits a catalog into entries
+func parsePO(content string) ([]*poEntry, error) {
+	var entries []*poEntry
+	cur := &poEntry{}
+	var section string // keyword that continuation lines belong to
+	var msgctxt, msgid *string
+	msgstrIndex := 0
+
+	flush := func() error {
+		if len(cur.head) == 0 && len(cur.msgstr) == 0 {
+			return nil
+		}
+		if !cur.obsolete {
+			if msgid == nil {
+				return fmt.Errorf("entry without msgid: %s", strings.Join(cur.head, " "))
+			}
+			if len(cur.values) == 0 {
+				cur.values = []string{""}
+			}
+			switch {
+			case msgctxt != nil:
+				cur.key = *msgctxt + PoContextSeparator + *msgid
+			case *msgid != "":
+				cur.key = *msgid
+			}
+		}
+		entries = append(entries, cur)
+		cur = &poEntry{}
+		section = ""
+		msgctxt, msgid = nil, nil
+		return nil
+	}
+
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		lineNo := i + 1
+		t := strings.TrimSpace(line)
+
+		switch {
+		case t == "":
+			if err := flush(); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+
+		case strings.HasPrefix(t, "#"):
+			// A comment after msgstr starts the next entry
+			if len(cur.msgstr) > 0 {
+				if err := flush(); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+			}
+			if strings.HasPrefix(t, "#~") {
+				cur.obsolete = true
+			}
+			if strings.HasPrefix(t, "#,") {
+				for _, flag := range strings.Split(t[2:], ",") {
+					if strings.TrimSpace(flag) == "fuzzy" {
+						cur.fuzzy = true
+					}
+				}
+			}
+			cur.head = append(cur.head, line)
+			continue
+
+		case strings.HasPrefix(t, "\""):
+			s, err := unquotePO(t)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			switch section {
+			case "msgctxt":
+				*msgctxt += s
+			case "msgid":
+				*msgid += s
+			case "msgid_plural":
+			case "msgstr":
+				cur.values[msgstrIndex] += s
+				cur.msgstr = append(cur.msgstr, line)
+				continue
+			default:
+				return nil, fmt.Errorf("line %d: unexpected string", lineNo)
+			}
+			cur.head = append(cur.head, line)
+			continue
+		}
+
+		keyword, rest, _ := strings.Cut(t, " ")
+		s, err := unquotePO(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		switch {
+		case strings.HasPrefix(keyword, "msgstr"):
+			section = "msgstr"
+			msgstrIndex = 0
+			if idx, ok := strings.CutPrefix(keyword, "msgstr["); ok {
+				n, err := strconv.Atoi(strings.TrimSuffix(idx, "]"))
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("line %d: invalid plural index %q", lineNo, keyword)
+				}
+				cur.plural = true
+				msgstrIndex = n
+			}
+			for len(cur.values) <= msgstrIndex {
+				cur.values = append(cur.values, "")
+			}
+			cur.values[msgstrIndex] = s
+			cur.msgstr = append(cur.msgstr, line)
+			continue
+
+		case keyword == "msgctxt" || keyword == "msgid":
+			// A new message without a separating blank line
+			if len(cur.msgstr) > 0 {
+				if err := flush(); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+			}
+			if keyword == "msgctxt" {
+				msgctxt = &s
+			} else {
+				msgid = &s
+			}
+
+		case keyword == "msgid_plural":
+			cur.plural = true
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNo, keyword)
+		}
+
+		section = keyword
+		cur.head = append(cur.head, line)
+	}
+
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("end of file: %w", err)
+	}
+	return entries, nil
+}
+
+// unquotePO decodes a C-style quoted PO string
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected quoted string, got %s", s)
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s: %w", s, err)
+	}
+	return v, nil
+}
+
+// quotePO encodes a string as a PO string literal
+func quotePO(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// formatPOString writes a keyword line, splitting multi-line values after
+// each newline the way gettext tools do
+func formatPOString(keyword, value string) []string {
+	if !strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		return []string{keyword + " " + quotePO(value)}
+	}
+
+	lines := []string{keyword + ` ""`}
+	for _, part := range strings.SplitAfter(value, "\n") {
+		if part != "" {
+			lines = append(lines, quotePO(part))
+		}
+	}
+	return lines
+}
+
+// formatMsgstr writes the msgstr lines of an entry
+func formatMsgstr(values []string, plural bool) []string {
+	if !plural {
+		return formatPOString("msgstr", values[0])
+	}
+
+	var lines []string
+	for i, v := range values {
+		lines = append(lines, formatPOString(fmt.Sprintf("msgstr[%d]", i), v)...)
+	}
+	return lines
+}
+
+// poValues converts an edited JSON value back to msgstr values
+func poValues(key string, v gjson.Result, plural bool) ([]string, error) {
+	if !v.IsArray() {
+		if v.IsObject() {
+			return nil, fmt.Errorf("message %q: objects are not supported in PO files", key)
+		}
+		return []string{v.String()}, nil
+	}
+
+	if !plural {
+		return nil, fmt.Errorf("message %q: plural values need a msgid_plural", key)
+	}
+	var strs []string
+	for _, item := range v.Array() {
+		strs = append(strs, item.String())
+	}
+	if len(strs) == 0 {
+		strs = []string{""}
+	}
+	return strs, nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const testPO = `# German translation
+msgid ""
+msgstr ""
+"Language: de\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"
+
+#. Shown on the start page
+#: src/home.c:12
+msgid "Welcome"
+msgstr "Willkommen"
+
+#, fuzzy
+msgid "File not found."
+msgstr "Datei fehlt."
+
+msgctxt "menu"
+msgid "Open"
+msgstr ""
+
+msgid "%d item"
+msgid_plural "%d items"
+msgstr[0] "%d Element"
+msgstr[1] "%d Elemente"
+
+msgid "Long"
+msgstr ""
+"first line\n"
+"second line"
+
+#~ msgid "Old"
+#~ msgstr "Alt"
+`
+
+func TestLoadPOFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "de.po")
+	if err := os.WriteFile(path, []byte(testPO), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	want := `{"Welcome":"Willkommen","File not found.":"Datei fehlt.","menu|Open":"","%d item":["%d Element","%d Elemente"],"Long":"first line\nsecond line"}`
+	if file.Data != want {
+		t.Errorf("LoadFile() Data = %s, want %s", file.Data, want)
+	}
+
+	if got := FuzzyKeys(file); !reflect.DeepEqual(got, []string{"File not found."}) {
+		t.Errorf("FuzzyKeys() = %v", got)
+	}
+}
+
+func TestSavePOFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "de.po")
+	if err := os.WriteFile(path, []byte(testPO), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	// Saving without changes reproduces the catalog
+	file.Dirty = true
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+	if saved, _ := os.ReadFile(path); string(saved) != testPO {
+		t.Errorf("SaveFile() without changes =\n%s\nwant\n%s", saved, testPO)
+	}
+
+	file.Data, _ = SetValue(file.Data, `menu\|Open`, "Öffnen")
+	file.Data, _ = SetValue(file.Data, `%d item.1`, "%d Dinge")
+	file.Data, _ = DeleteValue(file.Data, "Welcome")
+	file.Data, _ = SetValue(file.Data, `Save "all"`, "Alles speichern")
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	saved, _ := os.ReadFile(path)
+	got := string(saved)
+	for _, want := range []string{
+		"msgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"Öffnen\"\n",
+		"msgstr[0] \"%d Element\"\nmsgstr[1] \"%d Dinge\"\n",
+		"#, fuzzy\nmsgid \"File not found.\"\n",
+		"#~ msgid \"Old\"\n#~ msgstr \"Alt\"\n",
+		"msgid \"Save \\\"all\\\"\"\nmsgstr \"Alles speichern\"\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SaveFile() output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Willkommen") || strings.Contains(got, "src/home.c") {
+		t.Errorf("SaveFile() deleted entry still present:\n%s", got)
+	}
+
+	reloaded, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() after save error = %v", err)
+	}
+	if v := gjson.Get(reloaded.Data, `Save "all"`).String(); v != "Alles speichern" {
+		t.Errorf("reloaded value = %q", v)
+	}
+}
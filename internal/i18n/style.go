@@ -0,0 +1,178 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+var (
+	indentPattern     = regexp.MustCompile(`\n([ \t]+)\S`)
+	unicodeEscPattern = regexp.MustCompile(`\\u([0-9a-fA-F]{4})`)
+	htmlEscapePattern = regexp.MustCompile(`\\u00(3[cCeE]|26)`)
+)
+
+// stripBOM removes a leading UTF-8 byte order mark
+func stripBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, utf8BOM)
+}
+
+// DetectStyle inspects raw file content and returns its formatting style
+func DetectStyle(data []byte) types.FileStyle {
+	style := types.DefaultFileStyle()
+	if len(data) == 0 {
+		return style
+	}
+
+	style.BOM = bytes.HasPrefix(data, utf8BOM)
+	data = stripBOM(data)
+
+	if bytes.Contains(data, []byte("\r\n")) {
+		style.LineEnding = "\r\n"
+	}
+	style.FinalNewline = bytes.HasSuffix(data, []byte("\n"))
+
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.Contains(trimmed, []byte("\n")) {
+		// Single-line file: either minified or an empty object
+		if len(trimmed) > 2 {
+			style.Indent = ""
+		}
+	} else if m := indentPattern.FindSubmatch(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))); m != nil {
+		if m[1][0] == '\t' {
+			style.Indent = "\t"
+		} else {
+			style.Indent = string(m[1])
+		}
+	}
+
+	// Unicode escaping only counts if nothing is written as raw UTF-8
+	hasRawUnicode := false
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			hasRawUnicode = true
+			break
+		}
+	}
+	if !hasRawUnicode {
+		for _, m := range unicodeEscPattern.FindAllSubmatch(data, -1) {
+			var code int
+			fmt.Sscanf(string(m[1]), "%x", &code)
+			if code >= utf8.RuneSelf {
+				style.EscapeUnicode = true
+				break
+			}
+		}
+	}
+
+	style.EscapeHTML = htmlEscapePattern.Match(data)
+
+	return style
+}
+
+// applyStyle formats compact or indented JSON according to style
+func applyStyle(data []byte, style types.FileStyle) ([]byte, error) {
+	var formatted bytes.Buffer
+	var err error
+	if style.Indent == "" {
+		err = json.Compact(&formatted, data)
+	} else {
+		err = json.Indent(&formatted, data, "", style.Indent)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to format JSON: %w", err)
+	}
+
+	// Raw newlines can only appear between tokens, never inside JSON strings
+	return applyLineStyle(rewriteStrings(formatted.Bytes(), style), style), nil
+}
+
+// applyLineStyle applies the final newline, line endings and BOM of style to
+// LF-terminated content
+func applyLineStyle(out []byte, style types.FileStyle) []byte {
+	// Encoders keep or add trailing whitespace, the style decides instead
+	out = bytes.TrimRight(out, " \t\r\n")
+
+	lineEnding := style.LineEnding
+	if lineEnding == "" {
+		lineEnding = "\n"
+	}
+	if style.FinalNewline {
+		out = append(out, '\n')
+	}
+	if lineEnding != "\n" {
+		out = bytes.ReplaceAll(out, []byte("\n"), []byte(lineEnding))
+	}
+	if style.BOM {
+		out = append(append([]byte{}, utf8BOM...), out...)
+	}
+
+	return out
+}
+
+// rewriteStrings adjusts escaping inside JSON string literals to match style.
+// Go's encoder (used by sjson) escapes HTML characters and keeps raw UTF-8,
+// so edited values are brought in line with the rest of the file.
+func rewriteStrings(data []byte, style types.FileStyle) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if !inString {
+			out.WriteByte(c)
+			if c == '"' {
+				inString = true
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			out.WriteByte(c)
+			inString = false
+		case c == '\\' && i+1 < len(data):
+			esc := data[i : i+2]
+			if data[i+1] == 'u' && i+6 <= len(data) && !style.EscapeHTML {
+				switch strings.ToLower(string(data[i+2 : i+6])) {
+				case "003c":
+					out.WriteByte('<')
+					i += 5
+					continue
+				case "003e":
+					out.WriteByte('>')
+					i += 5
+					continue
+				case "0026":
+					out.WriteByte('&')
+					i += 5
+					continue
+				}
+			}
+			out.Write(esc)
+			i++
+		case c >= utf8.RuneSelf && style.EscapeUnicode:
+			r, size := utf8.DecodeRune(data[i:])
+			if r > 0xFFFF {
+				r -= 0x10000
+				fmt.Fprintf(&out, "\\u%04x\\u%04x", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			} else {
+				fmt.Fprintf(&out, "\\u%04x", r)
+			}
+			i += size - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+
+	return out.Bytes()
+}
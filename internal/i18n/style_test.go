@@ -0,0 +1,123 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestDetectStyle(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want types.FileStyle
+	}{
+		{
+			name: "empty file",
+			data: "",
+			want: types.FileStyle{Indent: "  ", LineEnding: "\n"},
+		},
+		{
+			name: "four spaces with final newline",
+			data: "{\n    \"a\": {\n        \"b\": \"c\"\n    }\n}\n",
+			want: types.FileStyle{Indent: "    ", LineEnding: "\n", FinalNewline: true},
+		},
+		{
+			name: "tabs, CRLF and BOM",
+			data: "\xEF\xBB\xBF{\r\n\t\"a\": \"b\"\r\n}",
+			want: types.FileStyle{Indent: "\t", LineEnding: "\r\n", BOM: true},
+		},
+		{
+			name: "minified",
+			data: `{"a":"b"}`,
+			want: types.FileStyle{Indent: "", LineEnding: "\n"},
+		},
+		{
+			name: "unicode and html escapes",
+			data: "{\n  \"a\": \"\\u4f60\\u597d \\u0026\"\n}",
+			want: types.FileStyle{Indent: "  ", LineEnding: "\n", EscapeUnicode: true, EscapeHTML: true},
+		},
+		{
+			name: "raw unicode is not escaped style",
+			data: "{\n  \"a\": \"你好\",\n  \"b\": \"\\u00e9\"\n}",
+			want: types.FileStyle{Indent: "  ", LineEnding: "\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectStyle([]byte(tt.data)); got != tt.want {
+				t.Errorf("DetectStyle() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveFilePreservesStyle(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		key      string
+		value    string
+		wantLine string
+	}{
+		{
+			name:     "tabs, CRLF, BOM and final newline",
+			original: "\xEF\xBB\xBF{\r\n\t\"home\": {\r\n\t\t\"title\": \"Home\",\r\n\t\t\"desc\": \"Désolé\"\r\n\t},\r\n\t\"z\": []\r\n}\r\n",
+			key:      "home.title",
+			value:    "Home & \"Away\"",
+			wantLine: "\t\t\"title\": \"Home & \\\"Away\\\"\",\r",
+		},
+		{
+			name:     "escaped unicode",
+			original: "{\n    \"title\": \"\\u4f60\\u597d\",\n    \"other\": \"x\"\n}\n",
+			key:      "title",
+			value:    "再见 😀",
+			wantLine: "    \"title\": \"\\u518d\\u89c1 \\ud83d\\ude00\",",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "en-US.json")
+			if err := os.WriteFile(path, []byte(tt.original), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := LoadFile(path, "")
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			file.Data, err = SetValue(file.Data, tt.key, tt.value)
+			if err != nil {
+				t.Fatalf("SetValue() error = %v", err)
+			}
+			if err := SaveFile(file); err != nil {
+				t.Fatalf("SaveFile() error = %v", err)
+			}
+
+			saved, _ := os.ReadFile(path)
+			oldLines := strings.Split(tt.original, "\n")
+			newLines := strings.Split(string(saved), "\n")
+			if len(oldLines) != len(newLines) {
+				t.Fatalf("SaveFile() line count = %d, want %d:\n%s", len(newLines), len(oldLines), saved)
+			}
+
+			changed := 0
+			for i := range oldLines {
+				if oldLines[i] != newLines[i] {
+					changed++
+					if newLines[i] != tt.wantLine {
+						t.Errorf("SaveFile() changed line = %q, want %q", newLines[i], tt.wantLine)
+					}
+				}
+			}
+			if changed != 1 {
+				t.Errorf("SaveFile() changed %d lines, want 1:\n%s", changed, saved)
+			}
+		})
+	}
+}
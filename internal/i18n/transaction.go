@@ -0,0 +1,293 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// JournalName is the file an in-progress multi-file save is recorded in, so
+// that an interrupted save can be completed or rolled back on the next run.
+// It is written to the deepest directory containing all saved files, or next
+// to the first one if that directory is not writable, and holds absolute
+// paths, so it is found whatever directory i18nedt runs in.
+const JournalName = ".i18nedt-journal.json"
+
+// rename and writeFile are replaced in tests to simulate failures
+var (
+	rename    = os.Rename
+	writeFile = os.WriteFile
+)
+
+// journalEntry records one file taking part in a save
+type journalEntry struct {
+	Path   string `json:"path"`
+	Temp   string `json:"temp"`
+	Backup string `json:"backup,omitempty"` // empty if the file did not exist before
+}
+
+type journal struct {
+	Entries []journalEntry `json:"entries"`
+}
+
+// commitFiles writes all files as one transaction: every file is staged to its
+// .tmp sibling and validated, existing files are backed up, and only then are
+// the staged files renamed into place. A failure rolls back renamed files.
+func commitFiles(files []*types.I18nFile, resolve ConflictResolver) error {
+	entries := make([]journalEntry, 0, len(files))
+	hashes := make(map[string]string, len(files))
+
+	// Merge concurrent changes first: files sharing a path are rendered together
+	for _, file := range files {
+		if err := mergeConcurrentChanges(file, resolve); err != nil {
+			return err
+		}
+	}
+
+	// Stage every file next to its target
+	for _, file := range files {
+		if _, ok := hashes[file.Path]; ok {
+			continue
+		}
+		path, err := filepath.Abs(file.Path)
+		if err != nil {
+			removeTemps(entries)
+			return fmt.Errorf("failed to resolve %s: %w", file.Path, err)
+		}
+		content, err := stageFile(file)
+		if err != nil {
+			removeTemps(entries)
+			return err
+		}
+		entries = append(entries, journalEntry{Path: path, Temp: path + ".tmp"})
+		hashes[file.Path] = ContentHash(content)
+	}
+
+	// Back up files that are about to be replaced
+	for i, e := range entries {
+		if _, err := os.Stat(e.Path); os.IsNotExist(err) {
+			continue
+		}
+		if err := BackupFile(e.Path); err != nil {
+			removeBackups(entries)
+			removeTemps(entries)
+			return fmt.Errorf("failed to back up %s: %w", e.Path, err)
+		}
+		entries[i].Backup = e.Path + ".backup"
+	}
+
+	journalPath, err := writeJournalFor(entries)
+	if err != nil {
+		removeBackups(entries)
+		removeTemps(entries)
+		return err
+	}
+
+	// Move staged files into place
+	for _, e := range entries {
+		if err := rename(e.Temp, e.Path); err != nil {
+			if rbErr := rollback(journalPath, entries); rbErr != nil {
+				return fmt.Errorf("failed to rename temporary file to %s: %w (rollback failed: %v, see %s)", e.Path, err, rbErr, journalPath)
+			}
+			return fmt.Errorf("failed to rename temporary file to %s: %w (all files rolled back)", e.Path, err)
+		}
+	}
+
+	removeBackups(entries)
+	os.Remove(journalPath)
+
+	// The written content is the new baseline for change detection
+	for _, file := range files {
+		file.Original = file.Data
+		file.Hash = hashes[file.Path]
+	}
+
+	return nil
+}
+
+// stageFile formats the file and writes it to its .tmp sibling, verifying the
+// written content
+func stageFile(file *types.I18nFile) ([]byte, error) {
+	content, err := formatFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure directory exists
+	dir := GetDirectory(file.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tempFile := file.Path + ".tmp"
+	if err := os.WriteFile(tempFile, content, 0644); err != nil {
+		os.Remove(tempFile)
+		return nil, fmt.Errorf("failed to write temporary file %s: %w", tempFile, err)
+	}
+
+	// Read back to make sure what hit the disk is what we meant to write
+	written, err := os.ReadFile(tempFile)
+	if err != nil || string(written) != string(content) {
+		os.Remove(tempFile)
+		return nil, fmt.Errorf("failed to verify temporary file %s", tempFile)
+	}
+
+	return content, nil
+}
+
+// rollback restores the state before the save. It only relies on what is on
+// disk, so it also works for a journal left by an interrupted run.
+func rollback(journalPath string, entries []journalEntry) error {
+	var firstErr error
+	for _, e := range entries {
+		_, tempErr := os.Stat(e.Temp)
+		staged := tempErr == nil
+
+		switch {
+		case e.Backup != "":
+			if err := rename(e.Backup, e.Path); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to restore %s: %w", e.Path, err)
+			}
+		case !staged:
+			// New file that was already moved into place
+			if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("failed to remove %s: %w", e.Path, err)
+			}
+		}
+
+		if staged {
+			os.Remove(e.Temp)
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return os.Remove(journalPath)
+}
+
+func removeTemps(entries []journalEntry) {
+	for _, e := range entries {
+		os.Remove(e.Temp)
+	}
+}
+
+func removeBackups(entries []journalEntry) {
+	for _, e := range entries {
+		if e.Backup != "" {
+			os.Remove(e.Backup)
+		}
+	}
+}
+
+// journalPathFor returns the journal path of a save: the deepest directory
+// containing all entries
+func journalPathFor(entries []journalEntry) string {
+	dir := filepath.Dir(entries[0].Path)
+	for _, e := range entries[1:] {
+		for !strings.HasPrefix(e.Path, dir+string(filepath.Separator)) && filepath.Dir(dir) != dir {
+			dir = filepath.Dir(dir)
+		}
+	}
+	return filepath.Join(dir, JournalName)
+}
+
+// writeJournalFor records entries in the journal of their save and returns
+// its path. Files on unrelated paths share an unwritable parent like /, so the
+// journal then goes next to the first file, where a file was just staged.
+func writeJournalFor(entries []journalEntry) (string, error) {
+	j := &journal{Entries: entries}
+	path := journalPathFor(entries)
+	err := writeJournal(path, j)
+	if fallback := filepath.Join(filepath.Dir(entries[0].Path), JournalName); err != nil && fallback != path {
+		path, err = fallback, writeJournal(fallback, j)
+	}
+	return path, err
+}
+
+func writeJournal(path string, j *journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode save journal: %w", err)
+	}
+	if err := writeFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write save journal %s: %w", path, err)
+	}
+	return nil
+}
+
+func readJournal(path string) (*journal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read save journal %s: %w", path, err)
+	}
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("invalid save journal %s: %w", path, err)
+	}
+	return &j, nil
+}
+
+// FindJournals returns the journals of interrupted saves that may involve the
+// files at paths: those in their directories or any parent directory
+func FindJournals(paths []string) []string {
+	found := make(map[string]bool)
+	checked := make(map[string]bool)
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		for dir := filepath.Dir(abs); !checked[dir]; dir = filepath.Dir(dir) {
+			checked[dir] = true
+			journal := filepath.Join(dir, JournalName)
+			if _, err := os.Stat(journal); err == nil {
+				found[journal] = true
+			}
+		}
+	}
+
+	journals := make([]string, 0, len(found))
+	for journal := range found {
+		journals = append(journals, journal)
+	}
+	sort.Strings(journals)
+	return journals
+}
+
+// CompleteJournal finishes the interrupted save recorded in the journal at
+// path by moving the remaining staged files into place. Staged files were
+// fully validated before the journal was written.
+func CompleteJournal(path string) error {
+	j, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range j.Entries {
+		if _, err := os.Stat(e.Temp); os.IsNotExist(err) {
+			continue // already renamed
+		}
+		if err := rename(e.Temp, e.Path); err != nil {
+			return fmt.Errorf("failed to rename temporary file to %s: %w", e.Path, err)
+		}
+	}
+
+	removeBackups(j.Entries)
+	return os.Remove(path)
+}
+
+// RollbackJournal undoes the interrupted save recorded in the journal at path,
+// restoring all files from their backups
+func RollbackJournal(path string) error {
+	j, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+	return rollback(path, j.Entries)
+}
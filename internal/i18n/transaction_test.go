@@ -0,0 +1,153 @@
+package i18n
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestSaveAllFilesRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	enPath := filepath.Join(tmpDir, "en-US.json")
+	zhPath := filepath.Join(tmpDir, "zh-CN.json")
+	newPath := filepath.Join(tmpDir, "de-DE.json")
+	os.WriteFile(enPath, []byte(`{"a": "old"}`), 0644)
+	os.WriteFile(zhPath, []byte(`{"a": "旧"}`), 0644)
+
+	files := []*types.I18nFile{
+		{Path: enPath, Data: `{"a": "new"}`, Dirty: true},
+		{Path: newPath, Data: `{"a": "neu"}`, Dirty: true},
+		{Path: zhPath, Data: `{"a": "新"}`, Dirty: true},
+	}
+
+	// Fail renaming the last file into place
+	rename = func(from, to string) error {
+		if from == zhPath+".tmp" {
+			return errors.New("disk full")
+		}
+		return os.Rename(from, to)
+	}
+	defer func() { rename = os.Rename }()
+
+	count, err := SaveAllFiles(files)
+	if err == nil {
+		t.Fatal("SaveAllFiles() expected error")
+	}
+	if count != 0 {
+		t.Errorf("SaveAllFiles() count = %d, want 0", count)
+	}
+
+	if data, _ := os.ReadFile(enPath); string(data) != `{"a": "old"}` {
+		t.Errorf("en-US.json = %s, want original content", data)
+	}
+	if data, _ := os.ReadFile(zhPath); string(data) != `{"a": "旧"}` {
+		t.Errorf("zh-CN.json = %s, want original content", data)
+	}
+	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+		t.Error("de-DE.json should not exist after rollback")
+	}
+
+	// No leftovers
+	leftovers, _ := filepath.Glob(filepath.Join(tmpDir, "*.*.*"))
+	if len(leftovers) > 0 {
+		t.Errorf("unexpected leftover files: %v", leftovers)
+	}
+	if journals := FindJournals([]string{enPath}); len(journals) > 0 {
+		t.Errorf("journal should be removed after rollback, found %v", journals)
+	}
+}
+
+func TestJournalRecovery(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	enPath := filepath.Join(tmpDir, "en-US.json")
+	zhPath := filepath.Join(tmpDir, "locales", "zh-CN.json")
+	journalPath := filepath.Join(tmpDir, JournalName)
+	os.Mkdir(filepath.Dir(zhPath), 0755)
+
+	// Simulate a run interrupted after en-US.json was renamed into place
+	setup := func() {
+		os.WriteFile(enPath, []byte(`{"a": "new"}`), 0644)
+		os.WriteFile(enPath+".backup", []byte(`{"a": "old"}`), 0644)
+		os.WriteFile(zhPath, []byte(`{"a": "旧"}`), 0644)
+		os.WriteFile(zhPath+".backup", []byte(`{"a": "旧"}`), 0644)
+		os.WriteFile(zhPath+".tmp", []byte(`{"a": "新"}`), 0644)
+		writeJournal(journalPath, &journal{Entries: []journalEntry{
+			{Path: enPath, Temp: enPath + ".tmp", Backup: enPath + ".backup"},
+			{Path: zhPath, Temp: zhPath + ".tmp", Backup: zhPath + ".backup"},
+		}})
+	}
+
+	setup()
+	// The journal is found from any of the files
+	if journals := FindJournals([]string{zhPath}); len(journals) != 1 || journals[0] != journalPath {
+		t.Fatalf("FindJournals() = %v, want [%s]", journals, journalPath)
+	}
+	if err := CompleteJournal(journalPath); err != nil {
+		t.Fatalf("CompleteJournal() error = %v", err)
+	}
+	if data, _ := os.ReadFile(zhPath); string(data) != `{"a": "新"}` {
+		t.Errorf("CompleteJournal() zh-CN.json = %s", data)
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Error("CompleteJournal() should remove the journal")
+	}
+
+	setup()
+	if err := RollbackJournal(journalPath); err != nil {
+		t.Fatalf("RollbackJournal() error = %v", err)
+	}
+	if data, _ := os.ReadFile(enPath); string(data) != `{"a": "old"}` {
+		t.Errorf("RollbackJournal() en-US.json = %s", data)
+	}
+	if data, _ := os.ReadFile(zhPath); string(data) != `{"a": "旧"}` {
+		t.Errorf("RollbackJournal() zh-CN.json = %s", data)
+	}
+	if _, err := os.Stat(zhPath + ".tmp"); !os.IsNotExist(err) {
+		t.Error("RollbackJournal() should remove staged files")
+	}
+}
+
+func TestJournalFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+	enPath := filepath.Join(tmpDir, "app", "en.json")
+	dePath := filepath.Join(tmpDir, "lib", "de.json")
+
+	// The common parent of unrelated paths is not writable
+	writeFile = func(name string, data []byte, perm os.FileMode) error {
+		if filepath.Dir(name) == tmpDir {
+			return os.ErrPermission
+		}
+		return os.WriteFile(name, data, perm)
+	}
+	// Recovery finds the journal while files are moved into place
+	var journals []string
+	rename = func(from, to string) error {
+		journals = FindJournals([]string{enPath})
+		return os.Rename(from, to)
+	}
+	defer func() { writeFile, rename = os.WriteFile, os.Rename }()
+
+	files := []*types.I18nFile{
+		{Path: enPath, Data: `{"a": "A"}`, Dirty: true},
+		{Path: dePath, Data: `{"a": "B"}`, Dirty: true},
+	}
+	if _, err := SaveAllFiles(files); err != nil {
+		t.Fatalf("SaveAllFiles() error = %v", err)
+	}
+	if data, _ := os.ReadFile(dePath); !strings.Contains(string(data), `"B"`) {
+		t.Errorf("de.json = %s", data)
+	}
+	journalPath := filepath.Join(filepath.Dir(enPath), JournalName)
+	if len(journals) != 1 || journals[0] != journalPath {
+		t.Errorf("FindJournals() during the save = %v, want [%s]", journals, journalPath)
+	}
+	if _, err := os.Stat(journalPath); !os.IsNotExist(err) {
+		t.Error("the journal should be removed after the save")
+	}
+}
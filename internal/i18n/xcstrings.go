@@ -0,0 +1,386 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// xcstringsFormat reads and writes Xcode string catalogs (.xcstrings). A
+// catalog holds every locale in one file, so loading it yields one I18nFile per
+// locale that all share the path and the catalog state. Plural variations map
+// to an object keyed by plural category; anything else the catalog stores
+// (comments, extraction state, device variations) is left untouched.
+type xcstringsFormat struct{}
+
+// xcstringsCatalog is the format state shared by all locale views of a catalog
+type xcstringsCatalog struct {
+	raw     string                       // Catalog JSON as loaded
+	source  string                       // Source language
+	locales []string                     // Source language first, then sorted
+	loaded  map[string]map[string]string // Locale -> key -> JSON value as loaded
+	views   []*types.I18nFile
+	renames map[string]string // New key -> entry of the renamed key, without localizations
+}
+
+// clone copies the catalog; CloneFiles points its views at the copied files
+func (cat *xcstringsCatalog) clone() any {
+	c := *cat
+	c.locales = slices.Clone(cat.locales)
+	c.loaded = make(map[string]map[string]string, len(cat.loaded))
+	for locale, values := range cat.loaded {
+		c.loaded[locale] = maps.Clone(values)
+	}
+	c.views = slices.Clone(cat.views)
+	c.renames = maps.Clone(cat.renames)
+	return &c
+}
+
+func (xcstringsFormat) Name() string { return "Xcode string catalog" }
+
+func (xcstringsFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+
+	content := string(stripBOM(raw))
+	if strings.TrimSpace(content) == "" {
+		content = `{"sourceLanguage":"en","strings":{},"version":"1.0"}`
+	}
+	if !gjson.Valid(content) {
+		return fmt.Errorf("content is not valid JSON")
+	}
+
+	doc := gjson.Parse(content)
+	cat := &xcstringsCatalog{
+		raw:    content,
+		source: doc.Get("sourceLanguage").String(),
+		loaded: make(map[string]map[string]string),
+	}
+	if cat.source == "" {
+		return fmt.Errorf("missing sourceLanguage")
+	}
+
+	localeSet := map[string]bool{cat.source: true}
+	doc.Get("strings").ForEach(func(_, entry gjson.Result) bool {
+		entry.Get("localizations").ForEach(func(locale, _ gjson.Result) bool {
+			localeSet[locale.String()] = true
+			return true
+		})
+		return true
+	})
+	for locale := range localeSet {
+		if locale != cat.source {
+			cat.locales = append(cat.locales, locale)
+		}
+	}
+	sort.Strings(cat.locales)
+	cat.locales = append([]string{cat.source}, cat.locales...)
+
+	for _, locale := range cat.locales {
+		cat.loaded[locale] = catalogValues(doc, locale, locale == cat.source)
+	}
+
+	// Files named after the catalog (Localizable) get the source language
+	if _, ok := cat.loaded[file.Locale]; !ok {
+		file.Locale = cat.source
+	}
+	file.Data = catalogData(doc, cat.loaded[file.Locale])
+	file.State = cat
+	return nil
+}
+
+// Split returns one view per locale of a loaded catalog
+func (xcstringsFormat) Split(file *types.I18nFile) []*types.I18nFile {
+	cat, ok := file.State.(*xcstringsCatalog)
+	if !ok {
+		return []*types.I18nFile{file}
+	}
+
+	doc := gjson.Parse(cat.raw)
+	cat.views = nil
+	for _, locale := range cat.locales {
+		view := *file
+		view.Locale = locale
+		view.Data = catalogData(doc, cat.loaded[locale])
+		view.Original = view.Data
+		cat.views = append(cat.views, &view)
+	}
+	return cat.views
+}
+
+func (xcstringsFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	style.FinalNewline = true
+	if style.Indent == "" {
+		style.Indent = "  "
+	}
+
+	cat, _ := file.State.(*xcstringsCatalog)
+	if cat == nil {
+		cat = &xcstringsCatalog{
+			raw:     `{"sourceLanguage":"` + file.Locale + `","strings":{},"version":"1.0"}`,
+			source:  file.Locale,
+			locales: []string{file.Locale},
+			loaded:  map[string]map[string]string{},
+		}
+	}
+	views := cat.views
+	if len(views) == 0 {
+		views = []*types.I18nFile{file}
+	}
+
+	out := cat.raw
+	var err error
+	added := false
+	present := make(map[string]bool)
+
+	for _, view := range views {
+		loaded := cat.loaded[view.Locale]
+		values := gjson.Parse(view.Data)
+
+		values.ForEach(func(k, v gjson.Result) bool {
+			key := k.String()
+			present[key] = true
+			if loaded[key] == compactJSON(v.Raw) {
+				return true
+			}
+
+			entry := "strings." + gjson.Escape(key)
+			if !gjson.Get(out, entry).Exists() {
+				raw := "{}"
+				if r, ok := cat.renames[key]; ok {
+					raw = r
+				}
+				if out, err = sjson.SetRaw(out, entry, raw); err != nil {
+					return false
+				}
+				added = true
+			}
+			// An unchanged source value equal to the key needs no localization
+			if view.Locale == cat.source && v.Type == gjson.String && v.String() == key && loaded[key] == "" {
+				return true
+			}
+			out, err = setLocalization(out, entry+".localizations."+gjson.Escape(view.Locale), key, v)
+			return err == nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Remove localizations of deleted keys
+		for key := range loaded {
+			if values.Get(gjson.Escape(key)).Exists() {
+				continue
+			}
+			path := "strings." + gjson.Escape(key) + ".localizations." + gjson.Escape(view.Locale)
+			if out, err = sjson.Delete(out, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Keys deleted from every locale are removed from the catalog
+	if len(views) == len(cat.locales) {
+		for _, view := range views {
+			for key := range cat.loaded[view.Locale] {
+				if present[key] {
+					continue
+				}
+				if out, err = sjson.Delete(out, "strings."+gjson.Escape(key)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if added {
+		out = sortCatalogStrings(out)
+	}
+
+	formatted, err := applyStyle([]byte(out), style)
+	if err != nil {
+		return nil, err
+	}
+	return xcodeColons(formatted), nil
+}
+
+// renameKey records the comment and other properties of the entry of from in
+// source for key to in target
+func (xcstringsFormat) renameKey(source, target *types.I18nFile, from, to string) {
+	src, _ := source.State.(*xcstringsCatalog)
+	dst, _ := target.State.(*xcstringsCatalog)
+	if src == nil || dst == nil {
+		return
+	}
+	raw, ok := src.renames[from]
+	if entry := gjson.Get(src.raw, "strings."+gjson.Escape(from)); entry.Exists() {
+		raw, ok = entry.Raw, true
+	}
+	if !ok {
+		return
+	}
+	raw, err := sjson.Delete(raw, "localizations")
+	if err != nil || compactJSON(raw) == "{}" {
+		return
+	}
+	if dst.renames == nil {
+		dst.renames = make(map[string]string)
+	}
+	dst.renames[to] = raw
+}
+
+// catalogValues collects the values of one locale as JSON. Keys of the source
+// language without a localization use the key itself as their value, like
+// Xcode does.
+func catalogValues(doc gjson.Result, locale string, source bool) map[string]string {
+	values := make(map[string]string)
+	doc.Get("strings").ForEach(func(k, entry gjson.Result) bool {
+		key := k.String()
+		if entry.Get("shouldTranslate").Type == gjson.False && !source {
+			return true
+		}
+
+		loc := entry.Get("localizations." + gjson.Escape(locale))
+		switch {
+		case loc.Get("stringUnit.value").Exists():
+			values[key] = compactJSON(loc.Get("stringUnit.value").Raw)
+		case loc.Get("variations.plural").IsObject():
+			plural := make(map[string]string)
+			var categories []string
+			ok := true
+			loc.Get("variations.plural").ForEach(func(c, v gjson.Result) bool {
+				value := v.Get("stringUnit.value")
+				if !value.Exists() {
+					ok = false
+					return false
+				}
+				categories = append(categories, c.String())
+				plural[c.String()] = value.String()
+				return true
+			})
+			if ok {
+				values[key] = orderedObject(categories, plural)
+			}
+		case !loc.Exists() && source:
+			raw, _ := json.Marshal(key)
+			values[key] = string(raw)
+		}
+		return true
+	})
+	return values
+}
+
+// catalogData builds the data of a locale view in catalog order
+func catalogData(doc gjson.Result, values map[string]string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	doc.Get("strings").ForEach(func(k, _ gjson.Result) bool {
+		value, ok := values[k.String()]
+		if !ok {
+			return true
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k.String())
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.WriteString(value)
+		return true
+	})
+	buf.WriteByte('}')
+	return compactJSON(buf.String())
+}
+
+// setLocalization writes a value into the localization at path
+func setLocalization(doc, path, key string, v gjson.Result) (string, error) {
+	unit := func(value string) string {
+		state := "translated"
+		if value == "" {
+			state = "new"
+		}
+		raw, _ := json.Marshal(map[string]string{"state": state, "value": value})
+		return string(raw)
+	}
+
+	switch {
+	case v.Type == gjson.String:
+		if gjson.Get(doc, path+".stringUnit").Exists() {
+			return sjson.SetRaw(doc, path+".stringUnit", unit(v.String()))
+		}
+		return sjson.SetRaw(doc, path, `{"stringUnit":`+unit(v.String())+`}`)
+	case v.IsObject():
+		var err error
+		doc, err = sjson.SetRaw(doc, path, `{"variations":{"plural":{}}}`)
+		v.ForEach(func(c, item gjson.Result) bool {
+			if item.Type != gjson.String {
+				err = fmt.Errorf("plural value %s of key %s must be a string", c.String(), key)
+				return false
+			}
+			doc, err = sjson.SetRaw(doc, path+".variations.plural."+gjson.Escape(c.String()), `{"stringUnit":`+unit(item.String())+`}`)
+			return err == nil
+		})
+		return doc, err
+	}
+	return doc, fmt.Errorf("value of key %s must be a string or an object of plural forms", key)
+}
+
+// sortCatalogStrings orders the entries of the catalog by key, as Xcode does
+func sortCatalogStrings(doc string) string {
+	type entry struct{ key, raw string }
+	var entries []entry
+	gjson.Get(doc, "strings").ForEach(func(k, v gjson.Result) bool {
+		entries = append(entries, entry{k.String(), v.Raw})
+		return true
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(e.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.WriteString(e.raw)
+	}
+	buf.WriteByte('}')
+
+	sorted, err := sjson.SetRaw(doc, "strings", buf.String())
+	if err != nil {
+		return doc
+	}
+	return sorted
+}
+
+// xcodeColons writes object keys as "key" : value, the way Xcode does
+func xcodeColons(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString && c == '\\' && i+1 < len(data):
+			out.WriteByte(c)
+			out.WriteByte(data[i+1])
+			i++
+			continue
+		case c == '"':
+			inString = !inString
+		case !inString && c == ':':
+			out.WriteString(" :")
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,174 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+const testXCStrings = `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld items" : {
+      "localizations" : {
+        "de" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld Element"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld Elemente"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Hello" : {
+      "comment" : "Greeting",
+      "localizations" : {
+        "de" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "Hallo"
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+
+func TestLoadXCStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Localizable.xcstrings")
+	if err := os.WriteFile(path, []byte(testXCStrings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := LoadAllFiles([]types.FileSource{{Path: path}})
+	if err != nil {
+		t.Fatalf("LoadAllFiles() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("LoadAllFiles() returned %d files, want 2", len(files))
+	}
+
+	want := map[string]string{
+		"en": `{"%lld items":"%lld items","Hello":"Hello"}`,
+		"de": `{"%lld items":{"one":"%lld Element","other":"%lld Elemente"},"Hello":"Hallo"}`,
+	}
+	for _, file := range files {
+		if file.Path != path {
+			t.Errorf("view path = %s, want %s", file.Path, path)
+		}
+		if file.Data != want[file.Locale] {
+			t.Errorf("view %s Data = %s, want %s", file.Locale, file.Data, want[file.Locale])
+		}
+	}
+}
+
+func TestSaveXCStrings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Localizable.xcstrings")
+	if err := os.WriteFile(path, []byte(testXCStrings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := LoadAllFiles([]types.FileSource{{Path: path}})
+	if err != nil {
+		t.Fatalf("LoadAllFiles() error = %v", err)
+	}
+
+	// Only one view is edited, the catalog is still written once
+	de := FindFileByLocale(files, "de")
+	de.Dirty = true
+	de.Data = `{"%lld items":{"one":"%lld Element","other":"%lld Elemente"},"Hello":"Servus","Bye":"Tschüss"}`
+	FindFileByLocale(files, "en").Data = `{"%lld items":"%lld items","Hello":"Hello","Bye":"Bye"}`
+
+	count, err := SaveAllFiles(files)
+	if err != nil {
+		t.Fatalf("SaveAllFiles() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("SaveAllFiles() count = %d, want 1", count)
+	}
+
+	want := `{
+  "sourceLanguage" : "en",
+  "strings" : {
+    "%lld items" : {
+      "localizations" : {
+        "de" : {
+          "variations" : {
+            "plural" : {
+              "one" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld Element"
+                }
+              },
+              "other" : {
+                "stringUnit" : {
+                  "state" : "translated",
+                  "value" : "%lld Elemente"
+                }
+              }
+            }
+          }
+        }
+      }
+    },
+    "Bye" : {
+      "localizations" : {
+        "de" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "Tschüss"
+          }
+        }
+      }
+    },
+    "Hello" : {
+      "comment" : "Greeting",
+      "localizations" : {
+        "de" : {
+          "stringUnit" : {
+            "state" : "translated",
+            "value" : "Servus"
+          }
+        }
+      }
+    }
+  },
+  "version" : "1.0"
+}
+`
+	if got, _ := os.ReadFile(path); string(got) != want {
+		t.Errorf("saved catalog =\n%s\nwant\n%s", got, want)
+	}
+
+	// Deleting a key from every locale removes it from the catalog
+	for _, file := range files {
+		file.Dirty = true
+		file.Data = `{"Hello":` + map[string]string{"en": `"Hello"`, "de": `"Servus"`}[file.Locale] + `}`
+	}
+	if _, err := SaveAllFiles(files); err != nil {
+		t.Fatalf("SaveAllFiles() error = %v", err)
+	}
+	reloaded, err := LoadAllFiles([]types.FileSource{{Path: path}})
+	if err != nil {
+		t.Fatalf("LoadAllFiles() error = %v", err)
+	}
+	if got := FindFileByLocale(reloaded, "en").Data; got != `{"Hello":"Hello"}` {
+		t.Errorf("reloaded en Data = %s", got)
+	}
+}
@@ -0,0 +1,403 @@
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFormat reads and writes YAML locale files. The parsed node tree is kept
+// so comments, key order and scalar styles survive a round trip.
+type yamlFormat struct{}
+
+// yamlState is the format state stored on I18nFile.State for YAML files
+type yamlState struct {
+	doc     *yaml.Node // Document as loaded, updated in place on save
+	rootKey string     // Rails-style wrapper key (e.g. "en"), empty if none
+}
+
+// clone copies the state; the document is updated in place on save
+func (s *yamlState) clone() any {
+	return &yamlState{doc: copyNode(s.doc, make(map[*yaml.Node]*yaml.Node)), rootKey: s.rootKey}
+}
+
+// copyNode deep-copies a node, keeping aliases pointing into the copy
+func copyNode(node *yaml.Node, copies map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if c, ok := copies[node]; ok {
+		return c
+	}
+	c := *node
+	copies[node] = &c
+	c.Content = slices.Clone(node.Content)
+	for i, child := range node.Content {
+		c.Content[i] = copyNode(child, copies)
+	}
+	c.Alias = copyNode(node.Alias, copies)
+	return &c
+}
+
+func (yamlFormat) Name() string { return "YAML" }
+
+func (yamlFormat) Decode(file *types.I18nFile, raw []byte) error {
+	file.Style = DetectStyle(raw)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(stripBOM(raw), &doc); err != nil {
+		return err
+	}
+	state := &yamlState{doc: &doc}
+	file.State = state
+
+	if len(doc.Content) == 0 {
+		// Empty document
+		file.Style.FinalNewline = true
+		file.Data = "{}"
+		return nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("top level must be a mapping")
+	}
+
+	// Rails keeps the locale as the single root key: "en: {home: ...}"
+	if len(root.Content) == 2 && root.Content[1].Kind == yaml.MappingNode &&
+		file.Locale != "" && strings.EqualFold(root.Content[0].Value, file.Locale) {
+		state.rootKey = root.Content[0].Value
+		root = root.Content[1]
+	}
+
+	var buf bytes.Buffer
+	if err := writeNodeJSON(&buf, root); err != nil {
+		return err
+	}
+	file.Data = buf.String()
+	return nil
+}
+
+func (yamlFormat) Encode(file *types.I18nFile) ([]byte, error) {
+	style := fileStyle(file)
+	state, _ := file.State.(*yamlState)
+	if state == nil {
+		// New file
+		state = &yamlState{doc: &yaml.Node{}}
+		style.FinalNewline = true
+	}
+	if state.doc.Kind == 0 {
+		state.doc.Kind = yaml.DocumentNode
+	}
+
+	// Find the node holding the translations
+	var top, root *yaml.Node
+	if len(state.doc.Content) > 0 {
+		top = state.doc.Content[0]
+		root = top
+		if state.rootKey != "" {
+			root = top.Content[1]
+		}
+	} else if state.rootKey != "" {
+		// Empty file wrapped in its locale root
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: state.rootKey}
+		top = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, nil}}
+	}
+
+	data := gjson.Parse(file.Data)
+	root = checkAliases(syncNode(root, data), data, make(map[*yaml.Node]bool))
+	if state.rootKey != "" {
+		top.Content[1] = root
+	} else {
+		top = root
+	}
+	state.doc.Content = []*yaml.Node{top}
+
+	indent := len(style.Indent)
+	if indent < 2 || style.Indent == "\t" {
+		indent = 2 // YAML forbids tabs for indentation
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(state.doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	return applyLineStyle(buf.Bytes(), style), nil
+}
+
+// adoptLocaleRoots wraps empty YAML files in their locale root key when other
+// YAML files of the set have one, so Rails can load the files it creates
+func adoptLocaleRoots(files []*types.I18nFile) {
+	rooted := slices.ContainsFunc(files, func(file *types.I18nFile) bool {
+		state, ok := file.State.(*yamlState)
+		return ok && state.rootKey != ""
+	})
+	if !rooted {
+		return
+	}
+
+	for _, file := range files {
+		if _, ok := FormatFor(file.Path).(yamlFormat); !ok || file.Locale == "" {
+			continue
+		}
+		state, _ := file.State.(*yamlState)
+		switch {
+		case state == nil:
+			file.State = &yamlState{doc: &yaml.Node{}, rootKey: file.Locale}
+			file.Style.FinalNewline = true
+		case state.rootKey == "" && isEmptyDocument(state.doc):
+			state.doc.Content = nil
+			state.rootKey = file.Locale
+		}
+	}
+}
+
+// isEmptyDocument reports whether doc holds no entries
+func isEmptyDocument(doc *yaml.Node) bool {
+	return len(doc.Content) == 0 ||
+		(doc.Content[0].Kind == yaml.MappingNode && len(doc.Content[0].Content) == 0)
+}
+
+// yaml11Bools are plain scalars that YAML 1.1 parsers (e.g. Ruby's Psych)
+// read as booleans, so string values spelled like this must be quoted
+var yaml11Bools = map[string]bool{
+	"y": true, "yes": true, "n": true, "no": true,
+	"on": true, "off": true, "true": true, "false": true,
+}
+
+// writeNodeJSON writes a YAML node as JSON, keeping mapping key order
+func writeNodeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeNodeJSON(buf, n.Content[0])
+
+	case yaml.AliasNode:
+		return writeNodeJSON(buf, n.Alias)
+
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.ShortTag() == "!!merge" {
+				return fmt.Errorf("line %d: YAML merge keys are not supported", key.Line)
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			keyJSON, _ := json.Marshal(key.Value)
+			buf.Write(keyJSON)
+			buf.WriteByte(':')
+			if err := writeNodeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeNodeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case yaml.ScalarNode:
+		buf.WriteString(scalarJSON(n))
+	}
+
+	return nil
+}
+
+// scalarJSON converts a YAML scalar to its JSON representation
+func scalarJSON(n *yaml.Node) string {
+	switch n.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool", "!!int", "!!float":
+		var v interface{}
+		if err := n.Decode(&v); err == nil {
+			if f, ok := v.(float64); !ok || (!math.IsInf(f, 0) && !math.IsNaN(f)) {
+				if raw, err := json.Marshal(v); err == nil {
+					return string(raw)
+				}
+			}
+		}
+	}
+
+	// Strings, timestamps and anything JSON can't represent natively
+	raw, _ := json.Marshal(n.Value)
+	return string(raw)
+}
+
+// syncNode updates a YAML node to hold value, reusing the existing node (and
+// with it comments and styles) wherever the structure still matches
+func syncNode(node *yaml.Node, value gjson.Result) *yaml.Node {
+	// Aliases stay as they are while they still hold value
+	if node != nil && node.Kind == yaml.AliasNode {
+		if nodeHolds(node.Alias, value) {
+			return node
+		}
+		node = replaceNode(node, &yaml.Node{})
+	}
+
+	switch {
+	case value.IsObject():
+		if node == nil || node.Kind != yaml.MappingNode {
+			node = replaceNode(node, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+		}
+
+		existing := make(map[string]int)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			existing[node.Content[i].Value] = i
+		}
+
+		content := make([]*yaml.Node, 0, len(node.Content))
+		value.ForEach(func(k, v gjson.Result) bool {
+			if i, ok := existing[k.String()]; ok {
+				content = append(content, node.Content[i], syncNode(node.Content[i+1], v))
+			} else {
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k.String()}
+				content = append(content, key, syncNode(nil, v))
+			}
+			return true
+		})
+		node.Content = content
+		return node
+
+	case value.IsArray():
+		if node == nil || node.Kind != yaml.SequenceNode {
+			node = replaceNode(node, &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"})
+		}
+
+		items := value.Array()
+		content := make([]*yaml.Node, len(items))
+		for i, item := range items {
+			var old *yaml.Node
+			if i < len(node.Content) {
+				old = node.Content[i]
+			}
+			content[i] = syncNode(old, item)
+		}
+		node.Content = content
+		return node
+	}
+
+	// Scalars: keep the node untouched if the value did not change
+	if node != nil && node.Kind == yaml.ScalarNode {
+		if value.Type == gjson.String && node.ShortTag() == "!!str" && node.Value == value.String() {
+			return node
+		}
+		if value.Type != gjson.String && scalarJSON(node) == value.Raw {
+			return node
+		}
+	}
+
+	scalar := &yaml.Node{Kind: yaml.ScalarNode}
+	switch value.Type {
+	case gjson.String:
+		scalar.Tag = "!!str"
+		scalar.Value = value.String()
+		if strings.Contains(scalar.Value, "\n") {
+			scalar.Style = yaml.LiteralStyle
+		} else if yaml11Bools[strings.ToLower(scalar.Value)] {
+			scalar.Style = yaml.DoubleQuotedStyle
+		} else if node != nil && node.Kind == yaml.ScalarNode &&
+			(node.Style == yaml.SingleQuotedStyle || node.Style == yaml.DoubleQuotedStyle) {
+			scalar.Style = node.Style
+		}
+	case gjson.Number:
+		scalar.Tag = "!!float"
+		if !strings.ContainsAny(value.Raw, ".eE") {
+			scalar.Tag = "!!int"
+		}
+		scalar.Value = value.Raw
+	case gjson.True, gjson.False:
+		scalar.Tag = "!!bool"
+		scalar.Value = value.Raw
+	default:
+		scalar.Tag = "!!null"
+		scalar.Value = "null"
+	}
+
+	return replaceNode(node, scalar)
+}
+
+// checkAliases replaces the aliases in node whose anchor is no longer defined
+// before them or no longer holds their value with a copy of their value. It
+// walks node in document order, recording the anchors defined in anchors.
+func checkAliases(node *yaml.Node, value gjson.Result, anchors map[*yaml.Node]bool) *yaml.Node {
+	switch node.Kind {
+	case yaml.AliasNode:
+		if anchors[node.Alias] && nodeHolds(node.Alias, value) {
+			return node
+		}
+		return syncNode(replaceNode(node, &yaml.Node{}), value)
+
+	case yaml.MappingNode:
+		values := make(map[string]gjson.Result)
+		value.ForEach(func(k, v gjson.Result) bool {
+			values[k.String()] = v
+			return true
+		})
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i+1] = checkAliases(node.Content[i+1], values[node.Content[i].Value], anchors)
+		}
+
+	case yaml.SequenceNode:
+		items := value.Array()
+		for i := range node.Content {
+			node.Content[i] = checkAliases(node.Content[i], items[i], anchors)
+		}
+	}
+
+	if node.Anchor != "" {
+		anchors[node] = true
+	}
+	return node
+}
+
+// nodeHolds reports whether node holds value
+func nodeHolds(node *yaml.Node, value gjson.Result) bool {
+	var buf bytes.Buffer
+	if err := writeNodeJSON(&buf, node); err != nil {
+		return false
+	}
+	var got, want interface{}
+	if json.Unmarshal(buf.Bytes(), &got) != nil || json.Unmarshal([]byte(value.Raw), &want) != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// replaceNode moves the anchor and comments from old to the replacement node
+func replaceNode(old, replacement *yaml.Node) *yaml.Node {
+	if old != nil {
+		replacement.Anchor = old.Anchor
+		replacement.HeadComment = old.HeadComment
+		replacement.LineComment = old.LineComment
+		replacement.FootComment = old.FootComment
+	}
+	return replacement
+}
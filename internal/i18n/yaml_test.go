@@ -0,0 +1,279 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+func TestLoadYAMLFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		wantData string
+	}{
+		{
+			name:     "plain yaml",
+			filename: "en-US.yml",
+			content:  "home:\n  title: Home\n  count: 3\nlist:\n  - a\n  - b\n",
+			wantData: `{"home":{"title":"Home","count":3},"list":["a","b"]}`,
+		},
+		{
+			name:     "rails style root key",
+			filename: "en.yaml",
+			content:  "en:\n  home:\n    title: Home\n",
+			wantData: `{"home":{"title":"Home"}}`,
+		},
+		{
+			name:     "quoted strings stay strings",
+			filename: "de-DE.yml",
+			content:  "a: 'yes'\nb: \"123\"\nc: true\nd: 123\n",
+			wantData: `{"a":"yes","b":"123","c":true,"d":123}`,
+		},
+		{
+			name:     "empty file",
+			filename: "fr-FR.yml",
+			content:  "",
+			wantData: `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.filename)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := LoadFile(path, "")
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if file.Data != tt.wantData {
+				t.Errorf("LoadFile() Data = %s, want %s", file.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestSaveYAMLFilePreservesComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "en.yml")
+	original := `# Application strings
+en:
+  home:
+    # Shown in the browser tab
+    title: Home
+    subtitle: 'Welcome'
+  nav:
+    about: About # footer link
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	file.Data, _ = SetValue(file.Data, "home.title", "Start")
+	file.Data, _ = SetValue(file.Data, "nav.contact", "yes")
+	file.Data, _ = SetValue(file.Data, "home.body", "line one\nline two")
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	saved, _ := os.ReadFile(path)
+	got := string(saved)
+	for _, want := range []string{
+		"# Application strings\nen:\n",
+		"    # Shown in the browser tab\n    title: Start\n",
+		"    subtitle: 'Welcome'\n",
+		"    about: About # footer link\n",
+		`    contact: "yes"`,
+		"    body: |-\n      line one\n      line two\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("SaveFile() output missing %q:\n%s", want, got)
+		}
+	}
+
+	// Key order is kept: subtitle stays before the newly added body
+	if strings.Index(got, "subtitle") > strings.Index(got, "body") {
+		t.Errorf("SaveFile() changed key order:\n%s", got)
+	}
+
+	// Round trip
+	reloaded, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() after save error = %v", err)
+	}
+	if gjson.Get(reloaded.Data, "nav.contact").String() != "yes" {
+		t.Errorf("reloaded nav.contact = %s", gjson.Get(reloaded.Data, "nav.contact").Raw)
+	}
+	if gjson.Get(reloaded.Data, "home.body").String() != "line one\nline two" {
+		t.Errorf("reloaded home.body = %s", gjson.Get(reloaded.Data, "home.body").Raw)
+	}
+}
+
+func TestSaveNewYAMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zh-CN.yaml")
+
+	file, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	file.Data = `{"home":{"title":"首页"}}`
+	if err := SaveFile(file); err != nil {
+		t.Fatalf("SaveFile() error = %v", err)
+	}
+
+	saved, _ := os.ReadFile(path)
+	if string(saved) != "home:\n  title: 首页\n" {
+		t.Errorf("SaveFile() = %q", saved)
+	}
+}
+
+func TestSaveYAMLFileKeepsAliases(t *testing.T) {
+	original := `defaults: &defaults
+  ok: OK
+  cancel: Cancel
+brand: &brand Acme
+dialog:
+  buttons: *defaults
+  title: *brand
+footer: *brand
+`
+
+	tests := []struct {
+		name string
+		key  string
+		// Value set before saving, none to save unchanged
+		value string
+		want  string
+	}{
+		{
+			name: "unchanged",
+			want: original,
+		},
+		{
+			name:  "changed alias is expanded",
+			key:   "footer",
+			value: "Acme Inc.",
+			want:  strings.Replace(original, "footer: *brand", "footer: Acme Inc.", 1),
+		},
+		{
+			name:  "changed anchor expands its aliases",
+			key:   "brand",
+			value: "Acme Inc.",
+			want: `defaults: &defaults
+  ok: OK
+  cancel: Cancel
+brand: &brand Acme Inc.
+dialog:
+  buttons: *defaults
+  title: Acme
+footer: Acme
+`,
+		},
+		{
+			name:  "value below an alias",
+			key:   "dialog.buttons.ok",
+			value: "Yes",
+			want: `defaults: &defaults
+  ok: OK
+  cancel: Cancel
+brand: &brand Acme
+dialog:
+  buttons:
+    ok: "Yes"
+    cancel: Cancel
+  title: *brand
+footer: *brand
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "en.yml")
+			if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := LoadFile(path, "")
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if tt.key != "" {
+				file.Data, _ = SetValue(file.Data, tt.key, tt.value)
+			}
+			if err := SaveFile(file); err != nil {
+				t.Fatalf("SaveFile() error = %v", err)
+			}
+
+			saved, _ := os.ReadFile(path)
+			if string(saved) != tt.want {
+				t.Errorf("SaveFile() = %s, want %s", saved, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveNewYAMLFileInLocaleRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		en   string
+		de   string // empty: the file does not exist
+		want string
+	}{
+		{"new file", "en:\n  title: Home\n", "", "de:\n  title: Start\n"},
+		{"empty file", "en:\n  title: Home\n", "\n", "de:\n  title: Start\n"},
+		{"empty mapping", "en:\n  title: Home\n", "{}\n", "de:\n  title: Start\n"},
+		{"no root", "title: Home\n", "", "title: Start\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			en := filepath.Join(dir, "en.yml")
+			de := filepath.Join(dir, "de.yml")
+			if err := os.WriteFile(en, []byte(tt.en), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.de != "" {
+				if err := os.WriteFile(de, []byte(tt.de), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			files, err := LoadAllFiles([]types.FileSource{{Path: en}, {Path: de}})
+			if err != nil {
+				t.Fatalf("LoadAllFiles() error = %v", err)
+			}
+			files[1].Data = `{"title":"Start"}`
+			files[1].Dirty = true
+			if _, err := SaveAllFiles(files); err != nil {
+				t.Fatalf("SaveAllFiles() error = %v", err)
+			}
+
+			saved, _ := os.ReadFile(de)
+			if string(saved) != tt.want {
+				t.Errorf("de.yml = %q, want %q", saved, tt.want)
+			}
+			reloaded, err := LoadFile(de, "")
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if reloaded.Data != `{"title":"Start"}` {
+				t.Errorf("reloaded data = %s", reloaded.Data)
+			}
+		})
+	}
+}
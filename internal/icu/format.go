@@ -0,0 +1,138 @@
+package icu
+
+import (
+	"strconv"
+	"strings"
+)
+
+// String returns the message in compact form, e.g.
+// "{count, plural, one {# item} other {# items}}"
+func (m *Message) String() string {
+	var b strings.Builder
+	m.write(&b, "", false, false, false)
+	return b.String()
+}
+
+// Layout returns the message with each plural or select branch on its own
+// line, indented by depth:
+//
+//	{count, plural,
+//	  one {# item}
+//	  other {# items}
+//	}
+//
+// Parsing the result gives back the same message, as long as branch texts
+// contain no line breaks.
+func (m *Message) Layout() string {
+	var b strings.Builder
+	m.write(&b, "", true, false, false)
+	return b.String()
+}
+
+// write renders the message. inBranch is set for branch messages, which are
+// followed by '}', and inPlural for plural branches, where '#' has a meaning.
+func (m *Message) write(b *strings.Builder, indent string, layout, inBranch, inPlural bool) {
+	for i, part := range m.Parts {
+		switch part.Kind {
+		case TextPart:
+			// Syntax character right after the text, which a trailing
+			// apostrophe would quote
+			var next byte
+			if i+1 < len(m.Parts) {
+				next = '{'
+				if m.Parts[i+1].Kind == PoundPart {
+					next = '#'
+				}
+			} else if inBranch {
+				next = '}'
+			}
+			writeText(b, part.Text, next, inPlural)
+		case PoundPart:
+			b.WriteByte('#')
+		case ArgumentPart:
+			part.Argument.write(b, indent, layout)
+		}
+	}
+}
+
+func (a *Argument) write(b *strings.Builder, indent string, layout bool) {
+	b.WriteByte('{')
+	b.WriteString(a.Name)
+	if a.Type == "" {
+		b.WriteByte('}')
+		return
+	}
+	b.WriteString(", ")
+	b.WriteString(a.Type)
+
+	if len(a.Branches) == 0 {
+		if a.Style != "" {
+			b.WriteString(", ")
+			b.WriteString(a.Style)
+		}
+		b.WriteByte('}')
+		return
+	}
+
+	b.WriteByte(',')
+	if a.Offset != 0 {
+		b.WriteString(" offset:")
+		b.WriteString(strconv.Itoa(a.Offset))
+	}
+	branchIndent := indent + "  "
+	for _, branch := range a.Branches {
+		if layout {
+			b.WriteByte('\n')
+			b.WriteString(branchIndent)
+		} else {
+			b.WriteByte(' ')
+		}
+		b.WriteString(branch.Selector)
+		b.WriteString(" {")
+		branch.Message.write(b, branchIndent, layout, true, a.Type != "select")
+		b.WriteByte('}')
+	}
+	if layout {
+		b.WriteByte('\n')
+		b.WriteString(indent)
+	}
+	b.WriteByte('}')
+}
+
+// writeText writes literal text, quoting syntax characters. next is the
+// syntax character following the text, if any.
+func writeText(b *strings.Builder, text string, next byte, inPlural bool) {
+	special := func(c byte) bool {
+		return c == '{' || c == '}' || c == '#' && inPlural
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '\'':
+			following := next
+			if i+1 < len(text) {
+				following = text[i+1]
+			}
+			// A lone apostrophe is literal unless it could start quoting
+			if following == '\'' || following == '|' || following != 0 && special(following) {
+				b.WriteString("''")
+			} else {
+				b.WriteByte('\'')
+			}
+		case special(c):
+			// Quote a run of syntax characters, apostrophes inside are doubled
+			b.WriteByte('\'')
+			for ; i < len(text) && (special(text[i]) || text[i] == '\''); i++ {
+				if text[i] == '\'' {
+					b.WriteByte('\'')
+				}
+				b.WriteByte(text[i])
+			}
+			i--
+			b.WriteByte('\'')
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
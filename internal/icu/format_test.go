@@ -0,0 +1,67 @@
+package icu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMessageString(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"{count, plural, one {# item} other {# items}}", "{count, plural, one {# item} other {# items}}"},
+		{"{count,plural,one{# item}other{# items}}", "{count, plural, one {# item} other {# items}}"},
+		{"{n, plural, offset:1 =0 {nobody} other {you and # others}}", "{n, plural, offset:1 =0 {nobody} other {you and # others}}"},
+		{"Due {date, date, short} for {name}", "Due {date, date, short} for {name}"},
+		{"it's '{literal}' {n, plural, other {# '#'}}", "it's '{'literal'}' {n, plural, other {# '#'}}"},
+		{"{g, select, other {it''s}}", "{g, select, other {it's}}"},
+		{"{g, select, other {rock''n''roll''}}", "{g, select, other {rock'n'roll''}}"},
+		{"a '{'''", "a '{'''"},
+	}
+
+	for _, tt := range tests {
+		msg, err := Parse(tt.message)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.message, err)
+		}
+		got := msg.String()
+		if got != tt.want {
+			t.Errorf("String() of %q = %q, want %q", tt.message, got, tt.want)
+		}
+
+		// The compact form must describe the same message
+		again, err := Parse(got)
+		if err != nil || again.String() != got {
+			t.Errorf("String() of %q does not round-trip: %q, %v", tt.message, got, err)
+		}
+	}
+}
+
+func TestMessageLayout(t *testing.T) {
+	msg, err := Parse("You have {gender, select, female {{count, plural, one {# item} other {# items}}} other {nothing}} now")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := strings.Join([]string{
+		"You have {gender, select,",
+		"  female {{count, plural,",
+		"    one {# item}",
+		"    other {# items}",
+		"  }}",
+		"  other {nothing}",
+		"} now",
+	}, "\n")
+	if got := msg.Layout(); got != want {
+		t.Errorf("Layout() =\n%s\nwant\n%s", got, want)
+	}
+
+	again, err := Parse(want)
+	if err != nil {
+		t.Fatalf("Parse(Layout()) error = %v", err)
+	}
+	if again.String() != msg.String() {
+		t.Errorf("Layout() does not round-trip: %q", again.String())
+	}
+}
@@ -0,0 +1,359 @@
+// Package icu parses ICU MessageFormat strings such as
+// "{count, plural, one {# item} other {# items}}".
+package icu
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PartKind identifies the kind of a message part
+type PartKind int
+
+const (
+	// TextPart is literal text, with quoting already resolved
+	TextPart PartKind = iota
+	// ArgumentPart is a {name...} argument
+	ArgumentPart
+	// PoundPart is the # inside a plural branch
+	PoundPart
+)
+
+// Message is a parsed ICU message
+type Message struct {
+	Parts []Part
+}
+
+// Part is one piece of a message
+type Part struct {
+	Kind     PartKind
+	Text     string
+	Argument *Argument
+}
+
+// Argument is a {name}, {name, type, style} or a plural/select argument
+type Argument struct {
+	Name     string
+	Type     string // Empty for simple arguments, otherwise number, date, plural, select, ...
+	Style    string // Raw style of simple typed arguments
+	Offset   int    // Plural offset
+	Branches []Branch
+}
+
+// Branch is one case of a plural or select argument
+type Branch struct {
+	Selector string // Plural category ("one"), explicit value ("=0") or select keyword
+	Message  *Message
+}
+
+// SyntaxError reports an invalid message
+type SyntaxError struct {
+	Offset int // Byte offset in the message
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+// Parse parses an ICU message
+func Parse(s string) (*Message, error) {
+	p := &parser{s: s}
+	msg, err := p.message(false)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected '}'")
+	}
+	return msg, nil
+}
+
+// IsComplex reports whether the message uses plural or select arguments
+func (m *Message) IsComplex() bool {
+	for _, part := range m.Parts {
+		if part.Kind == ArgumentPart && len(part.Argument.Branches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Arguments returns the sorted, unique argument names used anywhere in the message
+func (m *Message) Arguments() []string {
+	seen := make(map[string]bool)
+	m.collectArguments(seen)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Message) collectArguments(seen map[string]bool) {
+	for _, part := range m.Parts {
+		if part.Kind != ArgumentPart {
+			continue
+		}
+		seen[part.Argument.Name] = true
+		for _, b := range part.Argument.Branches {
+			b.Message.collectArguments(seen)
+		}
+	}
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// message parses text and arguments up to an unmatched '}' or the end
+func (p *parser) message(inPlural bool) (*Message, error) {
+	msg := &Message{}
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			msg.Parts = append(msg.Parts, Part{Kind: TextPart, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '\'':
+			p.quoted(&text, inPlural)
+		case c == '{':
+			flush()
+			arg, err := p.argument()
+			if err != nil {
+				return nil, err
+			}
+			msg.Parts = append(msg.Parts, Part{Kind: ArgumentPart, Argument: arg})
+		case c == '}':
+			flush()
+			return msg, nil
+		case c == '#' && inPlural:
+			flush()
+			msg.Parts = append(msg.Parts, Part{Kind: PoundPart, Text: "#"})
+			p.pos++
+		default:
+			text.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	flush()
+	return msg, nil
+}
+
+// quoted handles an apostrophe: a doubled apostrophe is a literal one, and one
+// before a syntax character starts quoted text up to the next apostrophe
+func (p *parser) quoted(text *strings.Builder, inPlural bool) {
+	p.pos++
+	if p.pos < len(p.s) && p.s[p.pos] == '\'' {
+		text.WriteByte('\'')
+		p.pos++
+		return
+	}
+	if p.pos >= len(p.s) || !(p.s[p.pos] == '{' || p.s[p.pos] == '}' || p.s[p.pos] == '|' || p.s[p.pos] == '#' && inPlural) {
+		text.WriteByte('\'')
+		return
+	}
+
+	for p.pos < len(p.s) {
+		if p.s[p.pos] == '\'' {
+			if p.pos+1 < len(p.s) && p.s[p.pos+1] == '\'' {
+				text.WriteByte('\'')
+				p.pos += 2
+				continue
+			}
+			p.pos++
+			return
+		}
+		text.WriteByte(p.s[p.pos])
+		p.pos++
+	}
+}
+
+// argument parses {name}, {name, type[, style]} and plural/select arguments
+func (p *parser) argument() (*Argument, error) {
+	start := p.pos
+	p.pos++ // {
+	p.skipSpace()
+
+	name := p.identifier()
+	if name == "" {
+		return nil, p.errorf("expected argument name")
+	}
+	arg := &Argument{Name: name}
+	p.skipSpace()
+
+	if p.pos >= len(p.s) {
+		p.pos = start
+		return nil, p.errorf("unclosed argument {%s", name)
+	}
+	if p.s[p.pos] == '}' {
+		p.pos++
+		return arg, nil
+	}
+	if p.s[p.pos] != ',' {
+		return nil, p.errorf("expected ',' or '}' after argument name %q", name)
+	}
+	p.pos++
+	p.skipSpace()
+
+	arg.Type = p.identifier()
+	if arg.Type == "" {
+		return nil, p.errorf("expected argument type for %q", name)
+	}
+	p.skipSpace()
+
+	switch arg.Type {
+	case "plural", "selectordinal", "select":
+		if p.pos >= len(p.s) || p.s[p.pos] != ',' {
+			return nil, p.errorf("expected ',' after %s", arg.Type)
+		}
+		p.pos++
+		if err := p.branches(arg); err != nil {
+			return nil, err
+		}
+	default:
+		if p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+			arg.Style = strings.TrimSpace(p.style())
+		}
+	}
+
+	if p.pos >= len(p.s) || p.s[p.pos] != '}' {
+		if p.pos >= len(p.s) {
+			p.pos = start
+			return nil, p.errorf("unclosed argument {%s", name)
+		}
+		return nil, p.errorf("expected '}' to close argument %q", name)
+	}
+	p.pos++
+	return arg, nil
+}
+
+// branches parses the cases of a plural or select argument
+func (p *parser) branches(arg *Argument) error {
+	plural := arg.Type != "select"
+	seen := make(map[string]bool)
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] == '}' {
+			break
+		}
+
+		if plural && strings.HasPrefix(p.s[p.pos:], "offset:") {
+			p.pos += len("offset:")
+			p.skipSpace()
+			n := p.identifier()
+			offset, err := strconv.Atoi(n)
+			if err != nil {
+				return p.errorf("invalid plural offset %q", n)
+			}
+			arg.Offset = offset
+			continue
+		}
+
+		selector := p.selector()
+		if selector == "" {
+			return p.errorf("expected selector in %s argument %q", arg.Type, arg.Name)
+		}
+		if seen[selector] {
+			return p.errorf("duplicate selector %q in argument %q", selector, arg.Name)
+		}
+		seen[selector] = true
+
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+			return p.errorf("expected '{' after selector %q", selector)
+		}
+		p.pos++
+		msg, err := p.message(plural)
+		if err != nil {
+			return err
+		}
+		if p.pos >= len(p.s) {
+			return p.errorf("unclosed branch %q of argument %q", selector, arg.Name)
+		}
+		p.pos++ // }
+
+		arg.Branches = append(arg.Branches, Branch{Selector: selector, Message: msg})
+	}
+
+	if len(arg.Branches) == 0 {
+		return p.errorf("%s argument %q has no branches", arg.Type, arg.Name)
+	}
+	if !seen["other"] {
+		return p.errorf("%s argument %q is missing the 'other' branch", arg.Type, arg.Name)
+	}
+	return nil
+}
+
+// style reads a simple argument style up to the closing brace
+func (p *parser) style() string {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\'':
+			if end := strings.IndexByte(p.s[p.pos+1:], '\''); end >= 0 {
+				p.pos += end + 1
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return p.s[start:p.pos]
+			}
+			depth--
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		r := rune(p.s[p.pos])
+		if r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) selector() string {
+	start := p.pos
+	if p.pos < len(p.s) && p.s[p.pos] == '=' {
+		p.pos++
+	}
+	if p.identifier() == "" {
+		p.pos = start
+		return ""
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
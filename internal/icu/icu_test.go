@@ -0,0 +1,72 @@
+package icu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArguments(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+		complex bool
+	}{
+		{"plain text", "Hello world", []string{}, false},
+		{"simple", "Hello {name}!", []string{"name"}, false},
+		{"typed", "Due {date, date, short} at {price, number, ::currency/EUR}", []string{"date", "price"}, false},
+		{"plural", "{count, plural, =0 {no items} one {# item} other {# items from {shop}}}", []string{"count", "shop"}, true},
+		{"select", "{gender, select, male {he} female {she} other {they}} liked it", []string{"gender"}, true},
+		{"offset", "{n, plural, offset:1 one {you} other {you and # others}}", []string{"n"}, true},
+		{"quoted braces", "Use '{name}' literally, it''s {real}", []string{"real"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Parse(tt.message)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := msg.Arguments(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Arguments() = %v, want %v", got, tt.want)
+			}
+			if got := msg.IsComplex(); got != tt.complex {
+				t.Errorf("IsComplex() = %v, want %v", got, tt.complex)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	msg, err := Parse("it''s '{literal}' {n, plural, other {# '#'}}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := msg.Parts[0].Text; got != "it's {literal} " {
+		t.Errorf("Parse() text = %q, want %q", got, "it's {literal} ")
+	}
+
+	branch := msg.Parts[1].Argument.Branches[0].Message
+	if len(branch.Parts) != 2 || branch.Parts[0].Kind != PoundPart || branch.Parts[1].Text != " #" {
+		t.Errorf("Parse() plural branch = %+v", branch.Parts)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"Hello {name",
+		"Hello {}",
+		"Hello name}",
+		"{count, plural, one {# item}}",
+		"{count, plural, one {# item} one {x} other {y}}",
+		"{count, plural, one # item other {# items}}",
+		"{gender, select, male {he} other {they}",
+		"{a b}",
+	}
+
+	for _, message := range tests {
+		if _, err := Parse(message); err == nil {
+			t.Errorf("Parse(%q) expected error", message)
+		}
+	}
+}
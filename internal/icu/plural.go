@@ -0,0 +1,129 @@
+package icu
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// pluralCategories lists the CLDR plural categories in their usual order
+var pluralCategories = []struct {
+	name string
+	form plural.Form
+}{
+	{"zero", plural.Zero},
+	{"one", plural.One},
+	{"two", plural.Two},
+	{"few", plural.Few},
+	{"many", plural.Many},
+	{"other", plural.Other},
+}
+
+var categoryCache sync.Map // "locale/ordinal" -> []string
+
+// PluralCategories returns the CLDR plural categories used by locale, for
+// cardinal ("plural") or ordinal ("selectordinal") numbers
+func PluralCategories(locale string, ordinal bool) ([]string, error) {
+	cacheKey := fmt.Sprintf("%s/%t", locale, ordinal)
+	if names, ok := categoryCache.Load(cacheKey); ok {
+		return names.([]string), nil
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locale %s: %w", locale, err)
+	}
+
+	rules := plural.Cardinal
+	if ordinal {
+		rules = plural.Ordinal
+	}
+
+	// x/text has no list of the forms of a language, so collect the forms of
+	// sample numbers: integers, powers of ten and one or two decimals
+	used := make(map[plural.Form]bool)
+	for i := 0; i <= 1000; i++ {
+		used[rules.MatchPlural(tag, i, 0, 0, 0, 0)] = true
+	}
+	for n := 10000; n <= 10000000; n *= 10 {
+		used[rules.MatchPlural(tag, n, 0, 0, 0, 0)] = true
+	}
+	if !ordinal {
+		for i := 0; i <= 20; i++ {
+			for f := 0; f < 100; f++ {
+				w, t := 2, f
+				for w > 0 && t%10 == 0 {
+					w, t = w-1, t/10
+				}
+				used[rules.MatchPlural(tag, i, 2, w, f, t)] = true
+				if f < 10 {
+					used[rules.MatchPlural(tag, i, 1, min(f, 1), f, f)] = true
+				}
+			}
+		}
+	}
+
+	var names []string
+	for _, c := range pluralCategories {
+		if used[c.form] {
+			names = append(names, c.name)
+		}
+	}
+	categoryCache.Store(cacheKey, names)
+	return names, nil
+}
+
+// MissingCategories describes a plural argument lacking branches for some of
+// the plural categories of a locale
+type MissingCategories struct {
+	Argument string
+	Missing  []string
+}
+
+// MissingPluralCategories returns the plural and selectordinal arguments of
+// the message, including nested ones, that have no branch for some category
+// used by locale. Explicit values like "=1" do not count as categories.
+func (m *Message) MissingPluralCategories(locale string) ([]MissingCategories, error) {
+	var result []MissingCategories
+	var walk func(msg *Message) error
+	walk = func(msg *Message) error {
+		for _, part := range msg.Parts {
+			if part.Kind != ArgumentPart {
+				continue
+			}
+			arg := part.Argument
+			if arg.Type == "plural" || arg.Type == "selectordinal" {
+				categories, err := PluralCategories(locale, arg.Type == "selectordinal")
+				if err != nil {
+					return err
+				}
+				present := make(map[string]bool)
+				for _, b := range arg.Branches {
+					present[b.Selector] = true
+				}
+				missing := MissingCategories{Argument: arg.Name}
+				for _, c := range categories {
+					if !present[c] {
+						missing.Missing = append(missing.Missing, c)
+					}
+				}
+				if len(missing.Missing) > 0 {
+					result = append(result, missing)
+				}
+			}
+			for _, b := range arg.Branches {
+				if err := walk(b.Message); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(m); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package icu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		locale  string
+		ordinal bool
+		want    []string
+	}{
+		{"en-US", false, []string{"one", "other"}},
+		{"en", true, []string{"one", "two", "few", "other"}},
+		{"ja", false, []string{"other"}},
+		{"ru", false, []string{"one", "few", "many", "other"}},
+		{"pl-PL", false, []string{"one", "few", "many", "other"}},
+		{"ar", false, []string{"zero", "one", "two", "few", "many", "other"}},
+	}
+
+	for _, tt := range tests {
+		got, err := PluralCategories(tt.locale, tt.ordinal)
+		if err != nil {
+			t.Fatalf("PluralCategories(%s) error = %v", tt.locale, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("PluralCategories(%s, %v) = %v, want %v", tt.locale, tt.ordinal, got, tt.want)
+		}
+	}
+
+	if _, err := PluralCategories("not a locale!", false); err == nil {
+		t.Error("PluralCategories() should fail for an invalid locale")
+	}
+}
+
+func TestMissingPluralCategories(t *testing.T) {
+	msg, err := Parse("{count, plural, =0 {none} one {# plik} other {# plików}} {place, selectordinal, other {#.}}")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	got, err := msg.MissingPluralCategories("pl")
+	if err != nil {
+		t.Fatalf("MissingPluralCategories() error = %v", err)
+	}
+	want := []MissingCategories{{Argument: "count", Missing: []string{"few", "many"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MissingPluralCategories(pl) = %+v, want %+v", got, want)
+	}
+
+	if got, _ := msg.MissingPluralCategories("ja"); len(got) != 0 {
+		t.Errorf("MissingPluralCategories(ja) = %+v, want none", got)
+	}
+}
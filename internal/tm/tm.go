@@ -0,0 +1,252 @@
+// Package tm is a translation memory built from the loaded locale files. It
+// suggests translations for a text from the keys that already translate the
+// same or a similar text.
+package tm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// MinScore is the similarity a fuzzy match needs to be suggested
+const MinScore = 0.75
+
+// maxSuggestions is the number of suggestions shown per locale
+const maxSuggestions = 3
+
+// Memory holds the string values of all keys by locale
+type Memory struct {
+	values map[string]map[string]string   // Key -> locale -> text
+	index  map[string]map[string][]string // Locale -> text -> keys
+}
+
+// Suggestion is a translation found in the memory
+type Suggestion struct {
+	Text   string  // The suggested translation
+	Key    string  // Key the translation was taken from
+	Source string  // Text of that key matching the text to translate
+	Score  float64 // 1 for an exact match, less for fuzzy ones
+}
+
+// Exact reports whether the suggestion translates exactly the same text
+func (s Suggestion) Exact() bool {
+	return s.Score == 1
+}
+
+// Build creates a memory from the non-empty string values of files
+func Build(files []*types.I18nFile, separator string) (*Memory, error) {
+	m := &Memory{
+		values: make(map[string]map[string]string),
+		index:  make(map[string]map[string][]string),
+	}
+	for _, file := range files {
+		flat, err := flatten.FlattenJSON([]byte(file.Data), file.Namespace, separator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+		for key, raw := range flat {
+			var text string
+			if json.Unmarshal([]byte(raw), &text) != nil || strings.TrimSpace(text) == "" {
+				continue
+			}
+			if m.values[key] == nil {
+				m.values[key] = make(map[string]string)
+			}
+			m.values[key][file.Locale] = text
+			if m.index[file.Locale] == nil {
+				m.index[file.Locale] = make(map[string][]string)
+			}
+			m.index[file.Locale][text] = append(m.index[file.Locale][text], key)
+		}
+	}
+	for _, texts := range m.index {
+		for _, keys := range texts {
+			sort.Strings(keys)
+		}
+	}
+	return m, nil
+}
+
+// Learn takes the string values of temp as the texts of their keys, so that
+// keys whose source text was only written in the editor get suggestions too
+func (m *Memory) Learn(temp *types.TempFile) {
+	for key, values := range temp.Content {
+		for locale, v := range values {
+			if v.Type != types.ValueTypeString || strings.TrimSpace(v.Value) == "" {
+				continue
+			}
+			if m.values[key] == nil {
+				m.values[key] = make(map[string]string)
+			}
+			m.values[key][locale] = v.Value
+		}
+	}
+}
+
+// Suggest returns translations into target for key, based on its values in
+// the other locales. Suggestions are ordered by score, exact matches first.
+func (m *Memory) Suggest(key, target string) []Suggestion {
+	var sourceLocales []string
+	for locale := range m.values[key] {
+		if locale != target {
+			sourceLocales = append(sourceLocales, locale)
+		}
+	}
+	sort.Strings(sourceLocales)
+
+	best := make(map[string]Suggestion) // Translation -> best suggestion
+	consider := func(s Suggestion) {
+		if old, ok := best[s.Text]; !ok || s.Score > old.Score {
+			best[s.Text] = s
+		}
+	}
+	for _, locale := range sourceLocales {
+		text := m.values[key][locale]
+		for candidate, keys := range m.index[locale] {
+			score := 1.0
+			if candidate != text {
+				if score = similarity(text, candidate); score < MinScore {
+					continue
+				}
+				// Texts differing only in case are close, but not exact
+				score = min(score, 0.99)
+			}
+			for _, k := range keys {
+				if translation, ok := m.values[k][target]; ok && k != key {
+					consider(Suggestion{Text: translation, Key: k, Source: candidate, Score: score})
+				}
+			}
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(best))
+	for _, s := range best {
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Text < b.Text
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// Annotate adds the suggestions for the empty string values of temp to its
+// context. With fill, values that have exactly one exact suggestion are set
+// to it. It returns the number of values filled.
+func (m *Memory) Annotate(temp *types.TempFile, fill bool) int {
+	filled := 0
+	for key, values := range temp.Content {
+		locales := make([]string, 0, len(values))
+		for locale, v := range values {
+			if v.Type == types.ValueTypeString && v.Value == "" {
+				locales = append(locales, locale)
+			}
+		}
+		sort.Strings(locales)
+
+		var lines []string
+		for _, locale := range locales {
+			suggestions := m.Suggest(key, locale)
+			if fill && len(suggestions) > 0 && suggestions[0].Exact() && (len(suggestions) == 1 || !suggestions[1].Exact()) {
+				values[locale] = types.NewStringValue(suggestions[0].Text)
+				lines = append(lines, fmt.Sprintf("%s: filled from translation memory (%s)", locale, suggestions[0].Key))
+				filled++
+				continue
+			}
+			for _, s := range suggestions {
+				lines = append(lines, fmt.Sprintf("%s suggestion: %s (%s)", locale, oneLine(s.Text), s.describe()))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if temp.Context == nil {
+			temp.Context = make(map[string]string)
+		}
+		if context := temp.Context[key]; context != "" {
+			lines = append([]string{context}, lines...)
+		}
+		temp.Context[key] = strings.Join(lines, "\n")
+	}
+	return filled
+}
+
+func (s Suggestion) describe() string {
+	if s.Exact() {
+		return "exact match, " + s.Key
+	}
+	return fmt.Sprintf("%d%% match of %q, %s", int(s.Score*100), oneLine(s.Source), s.Key)
+}
+
+// oneLine keeps a text on a single comment line
+func oneLine(s string) string {
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the
+// longer one, ignoring case
+func similarity(a, b string) float64 {
+	ra, rb := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	// Texts whose lengths differ too much cannot be similar enough
+	limit := int(float64(longest) * (1 - MinScore))
+	if abs(len(ra)-len(rb)) > limit {
+		return 0
+	}
+	d := levenshtein(ra, rb, limit)
+	if d > limit {
+		return 0
+	}
+	return 1 - float64(d)/float64(longest)
+}
+
+// levenshtein returns the edit distance of a and b, or a value above limit
+// once it is certain to exceed it
+func levenshtein(a, b []rune, limit int) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,130 @@
+package tm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func testMemory(t *testing.T) *Memory {
+	t.Helper()
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{
+			"cart": {"cancel": "Cancel order", "confirm": "Confirm order"},
+			"checkout": {"confirm": "Confirm order", "cancel": "Cancel orders", "title": "Checkout", "hint": "cancel order"},
+			"dialog": {"ok": "OK", "cancel": "Cancel"}
+		}`},
+		{Path: "de.json", Locale: "de", Data: `{
+			"cart": {"cancel": "Bestellung stornieren", "confirm": "Bestellung bestätigen"},
+			"checkout": {"confirm": "", "cancel": ""},
+			"dialog": {"ok": "OK", "cancel": "Abbrechen"}
+		}`},
+	}
+	m, err := Build(files, ":")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	return m
+}
+
+func TestSuggest(t *testing.T) {
+	m := testMemory(t)
+
+	tests := []struct {
+		key  string
+		want []Suggestion
+	}{
+		{"checkout.confirm", []Suggestion{{Text: "Bestellung bestätigen", Key: "cart.confirm", Source: "Confirm order", Score: 1}}},
+		{"checkout.cancel", []Suggestion{{Text: "Bestellung stornieren", Key: "cart.cancel", Source: "Cancel order", Score: 1 - 1.0/13}}},
+		{"checkout.title", nil},
+	}
+	for _, tt := range tests {
+		got := m.Suggest(tt.key, "de")
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Suggest(%q) = %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+
+	// A different case is a fuzzy match
+	if got := m.Suggest("checkout.hint", "de"); len(got) == 0 || got[0].Exact() || got[0].Key != "cart.cancel" {
+		t.Errorf("Suggest(checkout.hint) = %+v, want a fuzzy match of cart.cancel", got)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	m := testMemory(t)
+	newTemp := func() *types.TempFile {
+		return &types.TempFile{
+			Content: map[string]map[string]*types.Value{
+				"checkout.confirm": {"en": types.NewStringValue("Confirm order"), "de": types.NewStringValue("")},
+				"checkout.cancel":  {"en": types.NewStringValue("Cancel orders"), "de": types.NewStringValue("")},
+			},
+			Context: map[string]string{"checkout.cancel": "Cancels the purchase"},
+		}
+	}
+
+	temp := newTemp()
+	if filled := m.Annotate(temp, false); filled != 0 {
+		t.Errorf("Annotate() filled %d values without fill", filled)
+	}
+	want := map[string]string{
+		"checkout.confirm": "de suggestion: Bestellung bestätigen (exact match, cart.confirm)",
+		"checkout.cancel":  "Cancels the purchase\nde suggestion: Bestellung stornieren (92% match of \"Cancel order\", cart.cancel)",
+	}
+	if !reflect.DeepEqual(temp.Context, want) {
+		t.Errorf("Annotate() context = %q, want %q", temp.Context, want)
+	}
+
+	temp = newTemp()
+	if filled := m.Annotate(temp, true); filled != 1 {
+		t.Errorf("Annotate() filled %d values, want 1", filled)
+	}
+	if got := temp.Content["checkout.confirm"]["de"].Value; got != "Bestellung bestätigen" {
+		t.Errorf("Annotate() filled %q", got)
+	}
+	if got := temp.Content["checkout.cancel"]["de"].Value; got != "" {
+		t.Errorf("Annotate() filled a fuzzy match %q", got)
+	}
+	if got := temp.Context["checkout.confirm"]; got != "de: filled from translation memory (cart.confirm)" {
+		t.Errorf("Annotate() context = %q", got)
+	}
+}
+
+func TestLearn(t *testing.T) {
+	m := testMemory(t)
+
+	// A new key has no text to match until its source text is written
+	if got := m.Suggest("checkout.submit", "de"); len(got) != 0 {
+		t.Errorf("Suggest() of a new key = %+v, want none", got)
+	}
+	m.Learn(&types.TempFile{Content: map[string]map[string]*types.Value{
+		"checkout.submit": {"en": types.NewStringValue("Confirm order"), "de": types.NewStringValue("")},
+	}})
+	want := []Suggestion{{Text: "Bestellung bestätigen", Key: "cart.confirm", Source: "Confirm order", Score: 1}}
+	if got := m.Suggest("checkout.submit", "de"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest() after Learn() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Confirm", "Confirm", 1},
+		{"Confirm", "confirm", 1},
+		{"Cancel order", "Cancel orders", 1 - 1.0/13},
+		{"Cancel", "Cancel the whole order", 0},
+		{"abcd", "wxyz", 0},
+		{"", "", 1},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
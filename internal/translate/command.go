@@ -0,0 +1,46 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// commandProvider runs a command for every batch. It receives
+// {"from": ..., "to": ..., "texts": {key: text}} on stdin and must print a
+// JSON object mapping keys to translations.
+type commandProvider struct {
+	command string
+}
+
+func (p *commandProvider) Translate(ctx context.Context, from, to string, texts map[string]string) (map[string]string, error) {
+	input, err := json.Marshal(map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"texts": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(p.command)
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "I18NEDT_FROM="+from, "I18NEDT_TO="+to)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("command %s failed: %w", fields[0], err)
+	}
+
+	var translated map[string]string
+	if err := json.Unmarshal(output, &translated); err != nil {
+		return nil, fmt.Errorf("command %s did not print a JSON object: %w", fields[0], err)
+	}
+	return translated, nil
+}
@@ -0,0 +1,95 @@
+package translate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultDeepLEndpoint     = "https://api.deepl.com"
+	defaultDeepLFreeEndpoint = "https://api-free.deepl.com"
+)
+
+// deepLProvider uses the DeepL REST API, or a service with the same interface
+type deepLProvider struct {
+	client
+}
+
+func newDeepL(cfg Config) *deepLProvider {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultDeepLEndpoint
+		// Keys of the free API end in ":fx"
+		if strings.HasSuffix(cfg.APIKey, ":fx") {
+			endpoint = defaultDeepLFreeEndpoint
+		}
+	}
+	return &deepLProvider{client: newClient(endpoint, "DeepL-Auth-Key "+cfg.APIKey, cfg)}
+}
+
+func (p *deepLProvider) Translate(ctx context.Context, from, to string, texts map[string]string) (map[string]string, error) {
+	keys := make([]string, 0, len(texts))
+	for k := range texts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	request := struct {
+		Text       []string `json:"text"`
+		SourceLang string   `json:"source_lang"`
+		TargetLang string   `json:"target_lang"`
+	}{
+		SourceLang: deepLLanguage(from, false),
+		TargetLang: deepLLanguage(to, true),
+	}
+	for _, k := range keys {
+		request.Text = append(request.Text, texts[k])
+	}
+
+	var response struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := p.post(ctx, "/v2/translate", request, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Translations) != len(keys) {
+		return nil, fmt.Errorf("got %d translations for %d texts", len(response.Translations), len(keys))
+	}
+
+	translated := make(map[string]string, len(keys))
+	for i, k := range keys {
+		translated[k] = response.Translations[i].Text
+	}
+	return translated, nil
+}
+
+// deepLLanguage converts a locale like "pt_BR" to a DeepL language code.
+// Source languages have no region, and target languages only where DeepL
+// distinguishes them.
+func deepLLanguage(locale string, target bool) string {
+	parts := strings.FieldsFunc(strings.ToUpper(locale), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 {
+		return ""
+	}
+	lang := parts[0]
+	if !target || len(parts) < 2 {
+		return lang
+	}
+
+	switch lang {
+	case "EN", "PT":
+		return lang + "-" + parts[1]
+	case "ZH":
+		for _, p := range parts[1:] {
+			if p == "HANT" || p == "TW" || p == "HK" || p == "MO" {
+				return "ZH-HANT"
+			}
+		}
+		return "ZH-HANS"
+	}
+	return lang
+}
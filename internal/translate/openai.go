@@ -0,0 +1,87 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultOpenAIEndpoint = "https://api.openai.com/v1"
+	defaultOpenAIModel    = "gpt-4o-mini"
+)
+
+// openAIProvider uses an OpenAI-compatible chat completions endpoint
+type openAIProvider struct {
+	client
+	model string
+}
+
+func newOpenAI(cfg Config) *openAIProvider {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultOpenAIEndpoint
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &openAIProvider{
+		client: newClient(endpoint, "Bearer "+cfg.APIKey, cfg),
+		model:  model,
+	}
+}
+
+const openAIPrompt = `You translate the strings of an application from %s to %s.
+The user sends a JSON object mapping i18n keys to source texts. Reply with only a JSON object mapping the same keys to their translations.
+Keep placeholders such as {{name}}, {name}, %%s, %%1$d and $t(key), HTML tags and ICU message syntax unchanged; in ICU plural and select messages translate only the text of the branches and add the plural categories the target language needs.`
+
+func (p *openAIProvider) Translate(ctx context.Context, from, to string, texts map[string]string) (map[string]string, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	request := map[string]interface{}{
+		"model":       p.model,
+		"temperature": 0,
+		"messages": []map[string]string{
+			{"role": "system", "content": fmt.Sprintf(openAIPrompt, from, to)},
+			{"role": "user", "content": string(input)},
+		},
+	}
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := p.post(ctx, "/chat/completions", request, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("the response has no choices")
+	}
+
+	var translated map[string]string
+	content := stripCodeFence(response.Choices[0].Message.Content)
+	if err := json.Unmarshal([]byte(content), &translated); err != nil {
+		return nil, fmt.Errorf("the model did not reply with a JSON object: %w", err)
+	}
+	return translated, nil
+}
+
+// stripCodeFence removes a markdown code fence around a reply, which models
+// tend to add
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	if i := strings.Index(s, "\n"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
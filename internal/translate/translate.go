@@ -0,0 +1,196 @@
+// Package translate fills in missing translations with a machine translation
+// provider.
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// Provider translates texts from one locale to another. Texts and results are
+// keyed by i18n key, which providers may use as context.
+type Provider interface {
+	Translate(ctx context.Context, from, to string, texts map[string]string) (map[string]string, error)
+}
+
+// Providers lists the supported provider names
+var Providers = []string{"openai", "deepl", "command"}
+
+// Config selects and configures a provider
+type Config struct {
+	Provider string        // One of Providers
+	Endpoint string        // Base URL of the API, the provider default if empty
+	APIKey   string        // API key sent to HTTP providers
+	Model    string        // Model used by the openai provider
+	Command  string        // Command run by the command provider
+	Timeout  time.Duration // Timeout of a single request, none if zero
+}
+
+// NewProvider creates the provider described by cfg
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "openai":
+		return newOpenAI(cfg), nil
+	case "deepl":
+		return newDeepL(cfg), nil
+	case "command":
+		if strings.TrimSpace(cfg.Command) == "" {
+			return nil, fmt.Errorf("the command provider needs a command")
+		}
+		return &commandProvider{command: cfg.Command}, nil
+	}
+	return nil, fmt.Errorf("unknown translation provider %q (supported: %s)", cfg.Provider, strings.Join(Providers, ", "))
+}
+
+// Job holds the source texts to translate into one locale, by display key
+// (including the namespace)
+type Job struct {
+	To    string
+	Texts map[string]string
+}
+
+// Collect finds the keys that have a non-empty string value in the from locale
+// and are missing or empty in each of the to locales. keys restricts the
+// result to these keys and their children, all keys are used if it is empty.
+func Collect(files []*types.I18nFile, keys []string, from string, to []string, separator string) ([]Job, error) {
+	sources := make(map[string]string) // Display key -> source text
+	targets := make(map[string]map[string]string)
+	found := false
+
+	for _, file := range files {
+		if file.Locale != from && !contains(to, file.Locale) {
+			continue
+		}
+		flat, err := flatten.FlattenJSON([]byte(file.Data), file.Namespace, separator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+
+		prefix := ""
+		if file.Namespace != "" {
+			prefix = file.Namespace + separator
+		}
+		strs := make(map[string]string)
+		for k, raw := range flat {
+			var s string
+			if json.Unmarshal([]byte(raw), &s) == nil && selected(k, prefix, keys, separator) {
+				strs[k] = s
+			}
+		}
+
+		if file.Locale == from {
+			found = true
+			for k, s := range strs {
+				if s != "" {
+					sources[k] = s
+				}
+			}
+			continue
+		}
+		if targets[file.Locale] == nil {
+			targets[file.Locale] = make(map[string]string)
+		}
+		for k, s := range strs {
+			targets[file.Locale][k] = s
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no files found for source locale %s", from)
+	}
+
+	var jobs []Job
+	for _, locale := range to {
+		job := Job{To: locale, Texts: make(map[string]string)}
+		for k, s := range sources {
+			if targets[locale][k] == "" {
+				job.Texts[k] = s
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// selected reports whether the flattened key, whose namespace prefix is
+// prefix, is one of keys or below one of them. Keys may name a namespace.
+func selected(key, prefix string, keys []string, separator string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	for _, k := range keys {
+		for _, candidate := range []string{key, strings.TrimPrefix(key, prefix)} {
+			if candidate == k || strings.HasPrefix(candidate, k+".") || strings.HasSuffix(k, separator) && strings.HasPrefix(candidate, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Run translates the jobs in batches of at most batchSize texts and returns
+// the translations as a temporary file to apply with editor.ApplyChanges.
+// Keys the provider returns no translation for are left out. progress, if not
+// nil, is called after every batch.
+func Run(ctx context.Context, p Provider, from string, jobs []Job, batchSize int, separator string, progress func(to string, done, total int)) (*types.TempFile, error) {
+	temp := &types.TempFile{
+		Content:   make(map[string]map[string]*types.Value),
+		Deletes:   []string{},
+		Separator: separator,
+	}
+	for _, job := range jobs {
+		keys := make([]string, 0, len(job.Texts))
+		for k := range job.Texts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		size := batchSize
+		if size <= 0 {
+			size = len(keys)
+		}
+		for start := 0; start < len(keys); start += size {
+			end := min(start+size, len(keys))
+			batch := make(map[string]string, end-start)
+			for _, k := range keys[start:end] {
+				batch[k] = job.Texts[k]
+			}
+
+			translated, err := p.Translate(ctx, from, job.To, batch)
+			if err != nil {
+				return nil, fmt.Errorf("failed to translate into %s: %w", job.To, err)
+			}
+			for k := range batch {
+				text, ok := translated[k]
+				if !ok || text == "" {
+					continue
+				}
+				if temp.Content[k] == nil {
+					temp.Content[k] = make(map[string]*types.Value)
+					temp.Keys = append(temp.Keys, k)
+				}
+				temp.Content[k][job.To] = types.NewStringValue(text)
+			}
+			if progress != nil {
+				progress(job.To, end, len(keys))
+			}
+		}
+	}
+	sort.Strings(temp.Keys)
+	return temp, nil
+}
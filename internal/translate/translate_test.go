@@ -0,0 +1,254 @@
+package translate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// fakeProvider prefixes texts with the target locale and records the batches
+type fakeProvider struct {
+	batches []map[string]string
+}
+
+func (f *fakeProvider) Translate(_ context.Context, _, to string, texts map[string]string) (map[string]string, error) {
+	f.batches = append(f.batches, texts)
+	out := make(map[string]string)
+	for k, v := range texts {
+		if v != "skip" {
+			out[k] = to + ": " + v
+		}
+	}
+	return out, nil
+}
+
+func TestCollect(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: `{"home": {"title": "Home", "intro": "Hi", "count": 3}, "about": "About", "empty": ""}`},
+		{Path: "de/common.json", Locale: "de", Namespace: "common", Data: `{"home": {"title": "Start", "intro": ""}}`},
+		{Path: "fr/common.json", Locale: "fr", Namespace: "common", Data: `{}`},
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		want []Job
+	}{
+		{
+			name: "all keys",
+			want: []Job{
+				{To: "de", Texts: map[string]string{"common:home.intro": "Hi", "common:about": "About"}},
+				{To: "fr", Texts: map[string]string{"common:home.title": "Home", "common:home.intro": "Hi", "common:about": "About"}},
+			},
+		},
+		{
+			name: "selected keys",
+			keys: []string{"home"},
+			want: []Job{
+				{To: "de", Texts: map[string]string{"common:home.intro": "Hi"}},
+				{To: "fr", Texts: map[string]string{"common:home.title": "Home", "common:home.intro": "Hi"}},
+			},
+		},
+		{
+			name: "namespaced key",
+			keys: []string{"common:about"},
+			want: []Job{
+				{To: "de", Texts: map[string]string{"common:about": "About"}},
+				{To: "fr", Texts: map[string]string{"common:about": "About"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := Collect(files, tt.keys, "en", []string{"de", "fr"}, ":")
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if !reflect.DeepEqual(jobs, tt.want) {
+				t.Errorf("Collect() = %+v, want %+v", jobs, tt.want)
+			}
+		})
+	}
+
+	if _, err := Collect(files, nil, "ja", []string{"de"}, ":"); err == nil {
+		t.Error("Collect() should fail for an unknown source locale")
+	}
+}
+
+func TestRun(t *testing.T) {
+	jobs := []Job{
+		{To: "de", Texts: map[string]string{"a": "A", "b": "B", "c": "skip"}},
+		{To: "fr", Texts: map[string]string{"a": "A"}},
+	}
+	provider := &fakeProvider{}
+
+	var progress []string
+	temp, err := Run(context.Background(), provider, "en", jobs, 2, ":", func(to string, done, total int) {
+		progress = append(progress, fmt.Sprintf("%s %d/%d", to, done, total))
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(provider.batches) != 3 {
+		t.Errorf("Run() sent %d batches, want 3", len(provider.batches))
+	}
+	if want := []string{"de 2/3", "de 3/3", "fr 1/1"}; !reflect.DeepEqual(progress, want) {
+		t.Errorf("Run() progress = %v, want %v", progress, want)
+	}
+	want := map[string]map[string]*types.Value{
+		"a": {"de": types.NewStringValue("de: A"), "fr": types.NewStringValue("fr: A")},
+		"b": {"de": types.NewStringValue("de: B")},
+	}
+	if !reflect.DeepEqual(temp.Content, want) {
+		t.Errorf("Run() content = %+v, want %+v", temp.Content, want)
+	}
+	if !reflect.DeepEqual(temp.Keys, []string{"a", "b"}) || temp.Separator != ":" {
+		t.Errorf("Run() keys = %v, separator = %q", temp.Keys, temp.Separator)
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad request "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model    string
+			Messages []struct{ Role, Content string }
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "local" || !strings.Contains(req.Messages[0].Content, "from en-US to de-DE") {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		var texts map[string]string
+		json.Unmarshal([]byte(req.Messages[1].Content), &texts)
+		for k, v := range texts {
+			texts[k] = strings.ToUpper(v)
+		}
+		content, _ := json.Marshal(texts)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"content": "```json\n" + string(content) + "\n```"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(Config{Provider: "openai", Endpoint: server.URL + "/v1/", APIKey: "secret", Model: "local"})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	got, err := provider.Translate(context.Background(), "en-US", "de-DE", map[string]string{"home.title": "Home"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if want := map[string]string{"home.title": "HOME"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Translate() = %v, want %v", got, want)
+	}
+
+	provider, _ = NewProvider(Config{Provider: "openai", Endpoint: server.URL + "/v1", APIKey: "wrong"})
+	if _, err := provider.Translate(context.Background(), "en-US", "de-DE", map[string]string{"a": "A"}); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Translate() error = %v, want the HTTP status", err)
+	}
+}
+
+func TestDeepLProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text       []string `json:"text"`
+			SourceLang string   `json:"source_lang"`
+			TargetLang string   `json:"target_lang"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v2/translate" || r.Header.Get("Authorization") != "DeepL-Auth-Key key:fx" || req.SourceLang != "EN" || req.TargetLang != "PT-BR" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var translations []map[string]string
+		for _, text := range req.Text {
+			translations = append(translations, map[string]string{"text": "pt " + text})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"translations": translations})
+	}))
+	defer server.Close()
+
+	provider, _ := NewProvider(Config{Provider: "deepl", Endpoint: server.URL, APIKey: "key:fx"})
+	got, err := provider.Translate(context.Background(), "en-US", "pt_BR", map[string]string{"b": "Bye", "a": "Hello"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if want := map[string]string{"a": "pt Hello", "b": "pt Bye"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Translate() = %v, want %v", got, want)
+	}
+}
+
+func TestDeepLLanguage(t *testing.T) {
+	tests := []struct {
+		locale string
+		target bool
+		want   string
+	}{
+		{"en-US", false, "EN"},
+		{"en-US", true, "EN-US"},
+		{"de-DE", true, "DE"},
+		{"pt_BR", true, "PT-BR"},
+		{"zh-TW", true, "ZH-HANT"},
+		{"zh-Hant-HK", true, "ZH-HANT"},
+		{"zh-CN", true, "ZH-HANS"},
+		{"ja", true, "JA"},
+	}
+	for _, tt := range tests {
+		if got := deepLLanguage(tt.locale, tt.target); got != tt.want {
+			t.Errorf("deepLLanguage(%q, %v) = %q, want %q", tt.locale, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "fake-translate")
+	content := "#!/bin/sh\ncat > /dev/null\nprintf '{\"greeting\": \"%s hallo\"}' \"$I18NEDT_TO\"\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewProvider(Config{Provider: "command", Command: script})
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	got, err := provider.Translate(context.Background(), "en", "de", map[string]string{"greeting": "hello"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if want := map[string]string{"greeting": "de hallo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Translate() = %v, want %v", got, want)
+	}
+
+	provider, _ = NewProvider(Config{Provider: "command", Command: "false"})
+	if _, err := provider.Translate(context.Background(), "en", "de", map[string]string{"a": "A"}); err == nil {
+		t.Error("Translate() should fail when the command fails")
+	}
+	if _, err := NewProvider(Config{Provider: "command"}); err == nil {
+		t.Error("NewProvider() should fail without a command")
+	}
+	if _, err := NewProvider(Config{Provider: "babel"}); err == nil {
+		t.Error("NewProvider() should fail for an unknown provider")
+	}
+}
@@ -0,0 +1,719 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/mattn/go-runewidth"
+	"github.com/tidwall/gjson"
+
+	"github.com/kikyous/i18nedt/internal/editor"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// mode is what keyboard input currently goes to
+type mode int
+
+const (
+	browseMode mode = iota
+	searchMode
+	editMode
+	confirmQuitMode
+)
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	headerStyle   = lipgloss.NewStyle().Bold(true).Underline(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	missingStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	warnStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	cursorStyle   = lipgloss.NewStyle().Background(lipgloss.Color("236"))
+	cellStyle     = lipgloss.NewStyle().Background(lipgloss.Color("24")).Bold(true)
+)
+
+const helpText = "↑↓ move  ←→ column/fold  enter edit  space select  / search  ! issues  e $EDITOR  w write  q quit"
+
+// model is the bubbletea model of the key browser
+type model struct {
+	files     []*types.I18nFile
+	separator string
+	state     *State
+
+	entries []*entry
+	locales []string
+	root    *node
+	rows    []*node
+
+	cursor int // Row under the cursor
+	col    int // 0 is the key column, 1.. are locale columns
+	offset int // First row on screen
+
+	mode       mode
+	input      textinput.Model
+	editEntry  *entry
+	editLocale string
+	status     string
+	dirty      bool
+	result     Result
+
+	width, height int
+	zones         *zone.Manager
+}
+
+func newModel(files []*types.I18nFile, separator string, state *State) (*model, error) {
+	if state == nil {
+		state = &State{}
+	}
+	if state.Selected == nil {
+		state.Selected = make(map[string]bool)
+	}
+
+	m := &model{
+		files:     files,
+		separator: separator,
+		state:     state,
+		input:     textinput.New(),
+		status:    state.Status,
+	}
+	state.Status = ""
+	for _, file := range files {
+		if file.Dirty {
+			m.dirty = true
+		}
+	}
+	if err := m.refresh(); err != nil {
+		return nil, err
+	}
+
+	// Start with the top level unfolded
+	if state.Expanded == nil {
+		state.Expanded = make(map[string]bool)
+		for _, c := range m.root.children {
+			state.Expanded[c.id] = true
+		}
+		m.rebuildRows()
+	}
+
+	return m, nil
+}
+
+// refresh reloads entries and issues from the files
+func (m *model) refresh() error {
+	entries, locales, err := collectEntries(m.files, m.separator)
+	if err != nil {
+		return err
+	}
+	m.entries = entries
+	m.locales = locales
+	m.root = buildTree(entries)
+	m.rebuildRows()
+	return nil
+}
+
+// rebuildRows recomputes the visible rows, keeping the cursor on the same node
+func (m *model) rebuildRows() {
+	var match func(*entry) bool
+	search := strings.ToLower(m.state.Search)
+	if search != "" || m.state.IssuesOnly {
+		match = func(e *entry) bool {
+			if m.state.IssuesOnly && !e.hasIssues() {
+				return false
+			}
+			return search == "" || entryContains(e, search)
+		}
+	}
+	m.rows = visibleRows(m.root, m.state.Expanded, match)
+
+	m.cursor = 0
+	for i, n := range m.rows {
+		if n.id == m.state.Cursor {
+			m.cursor = i
+			break
+		}
+	}
+	m.moveCursor(0)
+}
+
+// entryContains reports whether the key or any value contains the lower-case query
+func entryContains(e *entry, query string) bool {
+	if strings.Contains(strings.ToLower(e.key), query) {
+		return true
+	}
+	for _, raw := range e.values {
+		if strings.Contains(strings.ToLower(displayValue(raw)), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) current() *node {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	return m.rows[m.cursor]
+}
+
+// moveCursor moves the cursor by delta rows and keeps it on screen
+func (m *model) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+
+	n := m.current()
+	if n == nil {
+		m.col = 0
+		return
+	}
+	m.state.Cursor = n.id
+	if n.entry == nil {
+		m.col = 0
+	}
+
+	if height := m.tableHeight(); height > 0 {
+		if m.cursor < m.offset {
+			m.offset = m.cursor
+		}
+		if m.cursor >= m.offset+height {
+			m.offset = m.cursor - height + 1
+		}
+	}
+}
+
+// tableHeight is the number of rows that fit on screen, 0 if unknown
+func (m *model) tableHeight() int {
+	if m.height == 0 {
+		return 0
+	}
+	if h := m.height - 3; h > 0 {
+		return h
+	}
+	return 1
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.moveCursor(0)
+		return m, nil
+	case tea.MouseMsg:
+		return m, m.handleMouse(msg)
+	case tea.KeyMsg:
+		switch m.mode {
+		case searchMode:
+			return m, m.updateSearch(msg)
+		case editMode:
+			return m, m.updateEdit(msg)
+		case confirmQuitMode:
+			return m, m.updateConfirmQuit(msg)
+		}
+		return m, m.updateBrowse(msg)
+	}
+	return m, nil
+}
+
+func (m *model) updateBrowse(msg tea.KeyMsg) tea.Cmd {
+	m.status = ""
+	n := m.current()
+
+	switch msg.String() {
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-max(m.tableHeight(), 10))
+	case "pgdown":
+		m.moveCursor(max(m.tableHeight(), 10))
+	case "home", "g":
+		m.moveCursor(-len(m.rows))
+	case "end", "G":
+		m.moveCursor(len(m.rows))
+
+	case "left", "h":
+		switch {
+		case m.col > 0:
+			m.col--
+		case n != nil && len(n.children) > 0 && m.state.Expanded[n.id]:
+			m.toggle(n)
+		case n != nil:
+			// Jump to the parent
+			for i := m.cursor - 1; i >= 0; i-- {
+				if m.rows[i].depth < n.depth {
+					m.moveCursor(i - m.cursor)
+					break
+				}
+			}
+		}
+	case "right", "l":
+		switch {
+		case n == nil:
+		case m.col == 0 && len(n.children) > 0 && !m.state.Expanded[n.id]:
+			m.toggle(n)
+		case n.entry != nil && m.col < len(m.locales):
+			m.col++
+		}
+
+	case "enter":
+		switch {
+		case n == nil:
+		case n.entry != nil:
+			if m.col == 0 {
+				m.col = 1
+			}
+			return m.startEdit(n.entry, m.locales[m.col-1])
+		default:
+			m.toggle(n)
+		}
+
+	case " ":
+		if n != nil {
+			m.toggleSelection(n)
+			m.moveCursor(1)
+		}
+
+	case "/":
+		m.mode = searchMode
+		m.input.Prompt = "/"
+		m.input.SetValue(m.state.Search)
+		m.input.CursorEnd()
+		return m.input.Focus()
+	case "esc":
+		if m.state.Search != "" {
+			m.state.Search = ""
+			m.rebuildRows()
+		}
+	case "!":
+		m.state.IssuesOnly = !m.state.IssuesOnly
+		m.rebuildRows()
+
+	case "e":
+		keys := m.selectedKeys()
+		if len(keys) == 0 && n != nil {
+			for _, e := range n.leaves() {
+				keys = append(keys, e.key)
+			}
+		}
+		if len(keys) == 0 {
+			m.status = "Nothing to edit"
+			return nil
+		}
+		m.result = Result{Action: Edit, Keys: keys}
+		return tea.Quit
+	case "w":
+		if !m.dirty {
+			m.status = "No changes to write"
+			return nil
+		}
+		m.result = Result{Action: Save}
+		return tea.Quit
+	case "q", "ctrl+c":
+		if m.dirty {
+			m.mode = confirmQuitMode
+			return nil
+		}
+		m.result = Result{Action: Quit}
+		return tea.Quit
+	}
+	return nil
+}
+
+func (m *model) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.mode = browseMode
+		m.input.Blur()
+		return nil
+	case "esc":
+		m.mode = browseMode
+		m.input.Blur()
+		m.state.Search = ""
+		m.rebuildRows()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.state.Search = m.input.Value()
+	m.rebuildRows()
+	return cmd
+}
+
+func (m *model) updateEdit(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		m.mode = browseMode
+		m.input.Blur()
+		m.applyEdit(m.input.Value())
+		return nil
+	case "esc":
+		m.mode = browseMode
+		m.input.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return cmd
+}
+
+func (m *model) updateConfirmQuit(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "w":
+		m.result = Result{Action: Save}
+		return tea.Quit
+	case "q", "ctrl+c":
+		m.result = Result{Action: Quit}
+		return tea.Quit
+	case "esc":
+		m.mode = browseMode
+	}
+	return nil
+}
+
+// toggle folds or unfolds a node
+func (m *model) toggle(n *node) {
+	if len(n.children) == 0 {
+		return
+	}
+	m.state.Expanded[n.id] = !m.state.Expanded[n.id]
+	m.rebuildRows()
+}
+
+// toggleSelection selects or deselects all keys under a node
+func (m *model) toggleSelection(n *node) {
+	leaves := n.leaves()
+	all := true
+	for _, e := range leaves {
+		if !m.state.Selected[e.key] {
+			all = false
+			break
+		}
+	}
+	for _, e := range leaves {
+		if all {
+			delete(m.state.Selected, e.key)
+		} else {
+			m.state.Selected[e.key] = true
+		}
+	}
+}
+
+// selectedKeys returns the selected keys that still exist, sorted
+func (m *model) selectedKeys() []string {
+	var keys []string
+	for _, e := range m.entries {
+		if m.state.Selected[e.key] {
+			keys = append(keys, e.key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// startEdit opens the inline editor for one cell
+func (m *model) startEdit(e *entry, locale string) tea.Cmd {
+	raw, exists := e.values[locale]
+	value := ""
+	if exists {
+		value = displayValue(raw)
+		if gjson.Parse(raw).Type == gjson.String && strings.Contains(value, "\n") {
+			m.status = "Multi-line value: press e to edit it in $EDITOR"
+			return nil
+		}
+	}
+
+	m.mode = editMode
+	m.editEntry = e
+	m.editLocale = locale
+	m.input.Prompt = fmt.Sprintf("%s [%s]: ", e.key, locale)
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// applyEdit writes the edited cell into the files through ApplyChanges, the
+// same way values from the editor are applied
+func (m *model) applyEdit(input string) {
+	e, locale := m.editEntry, m.editLocale
+
+	value := types.NewStringValue(input)
+	if raw, ok := e.values[locale]; ok && gjson.Parse(raw).Type != gjson.String && gjson.Valid(input) {
+		// Keep numbers and booleans typed
+		value = types.NewJSONValue(input)
+	}
+
+	found := false
+	for _, file := range m.files {
+		if file.Locale == locale && file.Namespace == e.namespace {
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.status = fmt.Sprintf("No file for locale %s, use e to create it in $EDITOR", locale)
+		return
+	}
+
+	temp := &types.TempFile{
+		Content:   map[string]map[string]*types.Value{e.key: {locale: value}},
+		Separator: m.separator,
+	}
+	if err := editor.ApplyChanges(m.files, temp); err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	for _, file := range m.files {
+		if file.Dirty {
+			m.dirty = true
+		}
+	}
+	if err := m.refresh(); err != nil {
+		m.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	m.status = fmt.Sprintf("Updated %s [%s], press w to write", e.key, locale)
+}
+
+func (m *model) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.moveCursor(-1)
+		return nil
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.moveCursor(1)
+		return nil
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionRelease || m.zones == nil || m.mode != browseMode:
+		return nil
+	}
+
+	end := len(m.rows)
+	if height := m.tableHeight(); height > 0 && m.offset+height < end {
+		end = m.offset + height
+	}
+	for i := m.offset; i < end; i++ {
+		for col := 0; col <= len(m.locales); col++ {
+			if !m.zones.Get(cellZone(i, col)).InBounds(msg) {
+				continue
+			}
+			m.moveCursor(i - m.cursor)
+			n := m.current()
+			if col == 0 {
+				m.toggle(n)
+			} else if n.entry != nil {
+				m.col = col
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func cellZone(row, col int) string {
+	return fmt.Sprintf("cell-%d-%d", row, col)
+}
+
+func (m *model) mark(id, s string) string {
+	if m.zones == nil {
+		return s
+	}
+	return m.zones.Mark(id, s)
+}
+
+// columnWidths returns the width of the key column and of each locale column
+func (m *model) columnWidths() (int, int) {
+	width := m.width
+	if width == 0 {
+		width = 120
+	}
+
+	keyWidth := 20
+	for _, n := range m.rows {
+		if w := 2*n.depth + 4 + runewidth.StringWidth(n.name); w > keyWidth {
+			keyWidth = w
+		}
+	}
+	if keyWidth > width*2/5 {
+		keyWidth = width * 2 / 5
+	}
+
+	if len(m.locales) == 0 {
+		return keyWidth, 0
+	}
+	localeWidth := (width - keyWidth - len(m.locales)) / len(m.locales)
+	if localeWidth < 8 {
+		localeWidth = 8
+	}
+	return keyWidth, localeWidth
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	keyWidth, localeWidth := m.columnWidths()
+
+	// Title
+	title := titleStyle.Render("i18nedt") + dimStyle.Render(fmt.Sprintf("  %d keys · %d locales", len(m.entries), len(m.locales)))
+	if n := len(m.selectedKeys()); n > 0 {
+		title += selectedStyle.Render(fmt.Sprintf("  %d selected", n))
+	}
+	if m.state.IssuesOnly {
+		title += warnStyle.Render("  issues only")
+	}
+	if m.state.Search != "" && m.mode != searchMode {
+		title += dimStyle.Render("  /" + m.state.Search)
+	}
+	if m.dirty {
+		title += warnStyle.Render("  [modified]")
+	}
+	b.WriteString(title + "\n")
+
+	// Column headers
+	b.WriteString(headerStyle.Render(pad("Key", keyWidth)))
+	for _, locale := range m.locales {
+		b.WriteString(" " + headerStyle.Render(pad(locale, localeWidth)))
+	}
+	b.WriteString("\n")
+
+	// Rows
+	end := len(m.rows)
+	if height := m.tableHeight(); height > 0 && m.offset+height < end {
+		end = m.offset + height
+	}
+	for i := m.offset; i < end; i++ {
+		b.WriteString(m.renderRow(i, keyWidth, localeWidth) + "\n")
+	}
+	if len(m.rows) == 0 {
+		b.WriteString(dimStyle.Render("  no keys match") + "\n")
+	}
+
+	// Footer
+	switch m.mode {
+	case searchMode, editMode:
+		b.WriteString(m.input.View())
+	case confirmQuitMode:
+		b.WriteString(warnStyle.Render("Unsaved changes: w write and quit, q discard, esc cancel"))
+	default:
+		if m.status != "" {
+			b.WriteString(m.status)
+		} else {
+			b.WriteString(dimStyle.Render(helpText))
+		}
+	}
+
+	if m.zones == nil {
+		return b.String()
+	}
+	return m.zones.Scan(b.String())
+}
+
+// renderRow renders one row of the table
+func (m *model) renderRow(i, keyWidth, localeWidth int) string {
+	n := m.rows[i]
+	isCursor := i == m.cursor
+
+	// Key column: selection mark, indentation, fold marker and name
+	mark := " "
+	leaves := n.leaves()
+	selected := 0
+	for _, e := range leaves {
+		if m.state.Selected[e.key] {
+			selected++
+		}
+	}
+	if selected > 0 {
+		mark = "●"
+		if selected < len(leaves) {
+			mark = "◐"
+		}
+	}
+
+	fold := "  "
+	if len(n.children) > 0 {
+		fold = "▸ "
+		if m.state.Expanded[n.id] || m.state.Search != "" || m.state.IssuesOnly {
+			fold = "▾ "
+		}
+	}
+
+	name := n.name
+	if n.entry == nil {
+		name += fmt.Sprintf(" (%d)", len(leaves))
+	}
+	keyCell := pad(mark+strings.Repeat("  ", n.depth)+fold+name, keyWidth)
+	switch {
+	case selected > 0:
+		keyCell = selectedStyle.Render(keyCell)
+	case n.entry != nil && n.entry.hasIssues():
+		keyCell = warnStyle.Render(keyCell)
+	}
+	if isCursor && m.col == 0 {
+		keyCell = cellStyle.Render(keyCell)
+	}
+
+	var b strings.Builder
+	b.WriteString(m.mark(cellZone(i, 0), keyCell))
+
+	for j, locale := range m.locales {
+		cell := pad("", localeWidth)
+		if n.entry != nil {
+			raw, ok := n.entry.values[locale]
+			switch {
+			case !ok:
+				cell = missingStyle.Render(pad("∅ missing", localeWidth))
+			case raw == `""`:
+				cell = warnStyle.Render(pad("(empty)", localeWidth))
+			case n.entry.issues[locale]:
+				cell = warnStyle.Render(pad(displayValue(raw), localeWidth))
+			default:
+				cell = pad(displayValue(raw), localeWidth)
+			}
+		}
+		if isCursor && m.col == j+1 {
+			cell = cellStyle.Render(cell)
+		}
+		b.WriteString(" " + m.mark(cellZone(i, j+1), cell))
+	}
+
+	row := b.String()
+	if isCursor {
+		row = cursorStyle.Render(row)
+	}
+	return row
+}
+
+// displayValue renders a raw JSON leaf value as text
+func displayValue(raw string) string {
+	v := gjson.Parse(raw)
+	if v.Type == gjson.String {
+		return v.String()
+	}
+	return raw
+}
+
+// pad truncates or pads s to exactly width cells on one line
+func pad(s string, width int) string {
+	s = strings.ReplaceAll(s, "\n", "⏎")
+	if lipgloss.Width(s) > width {
+		s = runewidth.Truncate(s, width, "…")
+	}
+	if w := lipgloss.Width(s); w < width {
+		s += strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func testModel(t *testing.T) (*model, []*types.I18nFile) {
+	t.Helper()
+	files := []*types.I18nFile{
+		{Path: "en.json", Locale: "en", Data: `{"home": {"title": "Home", "count": 3}, "nav": {"back": "Back"}}`},
+		{Path: "de.json", Locale: "de", Data: `{"home": {"title": "Startseite"}, "nav": {"back": ""}}`},
+	}
+	m, err := newModel(files, ":", nil)
+	if err != nil {
+		t.Fatalf("newModel() error = %v", err)
+	}
+	return m, files
+}
+
+func press(m *model, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "right":
+			msg = tea.KeyMsg{Type: tea.KeyRight}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		_, cmd = m.Update(msg)
+	}
+	return cmd
+}
+
+func rowNames(m *model) []string {
+	var names []string
+	for _, n := range m.rows {
+		names = append(names, n.name)
+	}
+	return names
+}
+
+func TestModelNavigation(t *testing.T) {
+	m, _ := testModel(t)
+
+	// Top level starts unfolded
+	if got := rowNames(m); !reflect.DeepEqual(got, []string{"home", "count", "title", "nav", "back"}) {
+		t.Errorf("rows = %v", got)
+	}
+
+	// Folding the current node hides its children
+	press(m, "h")
+	if got := rowNames(m); !reflect.DeepEqual(got, []string{"home", "nav", "back"}) {
+		t.Errorf("rows after fold = %v", got)
+	}
+
+	view := m.View()
+	for _, want := range []string{"home (2)", "(empty)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestModelSearchAndIssues(t *testing.T) {
+	m, _ := testModel(t)
+
+	press(m, "/", "s", "t", "a", "r", "t", "enter")
+	if got := rowNames(m); !reflect.DeepEqual(got, []string{"home", "title"}) {
+		t.Errorf("rows for search 'start' = %v", got)
+	}
+	press(m, "esc")
+
+	press(m, "!")
+	if got := rowNames(m); !reflect.DeepEqual(got, []string{"home", "count", "nav", "back"}) {
+		t.Errorf("rows with issues only = %v", got)
+	}
+}
+
+func TestModelInlineEdit(t *testing.T) {
+	m, files := testModel(t)
+
+	// Cursor to home.title, first locale column (de)
+	press(m, "down", "down", "enter")
+	if m.mode != editMode || m.input.Value() != "Startseite" {
+		t.Fatalf("edit mode = %v, input = %q", m.mode, m.input.Value())
+	}
+	press(m, "backspace", "backspace", "backspace", "backspace", "enter")
+
+	if files[1].Data != `{"home": {"title": "Starts"}, "nav": {"back": ""}}` || !files[1].Dirty {
+		t.Errorf("de.json Data = %s, Dirty = %v", files[1].Data, files[1].Dirty)
+	}
+	if files[0].Dirty {
+		t.Errorf("en.json should not be dirty")
+	}
+
+	// Typed values stay typed
+	press(m, "up", "right", "right", "enter", "backspace", "5", "enter")
+	if files[0].Data != `{"home": {"title": "Home", "count": 5}, "nav": {"back": "Back"}}` {
+		t.Errorf("en.json Data = %s", files[0].Data)
+	}
+
+	// Quitting with unsaved edits asks first
+	press(m, "q")
+	if m.mode != confirmQuitMode {
+		t.Fatalf("mode = %v, want confirmQuitMode", m.mode)
+	}
+	if cmd := press(m, "w"); cmd == nil || m.result.Action != Save {
+		t.Errorf("result = %+v, want Save", m.result)
+	}
+}
+
+func TestModelSelection(t *testing.T) {
+	m, _ := testModel(t)
+
+	// Selecting a node selects every key below it
+	press(m, " ")
+	press(m, "down", "down", " ")
+	if got := m.selectedKeys(); !reflect.DeepEqual(got, []string{"home.count", "home.title", "nav.back"}) {
+		t.Errorf("selectedKeys() = %v", got)
+	}
+
+	press(m, "e")
+	if m.result.Action != Edit || !reflect.DeepEqual(m.result.Keys, []string{"home.count", "home.title", "nav.back"}) {
+		t.Errorf("result = %+v", m.result)
+	}
+}
@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/doctor"
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// entry is one leaf key with its values in every locale
+type entry struct {
+	key       string // Display key as used with -k (ns:path)
+	namespace string
+	path      []string          // Tree path: namespace (if any) followed by key components
+	values    map[string]string // Locale -> raw JSON value
+	issues    map[string]bool   // Locales where doctor reports the key
+}
+
+// hasIssues reports whether doctor reports the key in any locale
+func (e *entry) hasIssues() bool {
+	return len(e.issues) > 0
+}
+
+// node is a namespace, an object key or a leaf key in the tree
+type node struct {
+	name     string
+	id       string // Unique path of the node, used to remember expansion
+	depth    int
+	children []*node
+	entry    *entry // Set for leaves
+}
+
+// collectEntries flattens all files into entries and returns them sorted by
+// key together with the sorted list of locales
+func collectEntries(files []*types.I18nFile, separator string) ([]*entry, []string, error) {
+	byKey := make(map[string]*entry)
+
+	for _, file := range files {
+		flat, err := flatten.FlattenJSON([]byte(file.Data), "", separator)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+
+		for path, value := range flat {
+			key := path
+			var treePath []string
+			if file.Namespace != "" {
+				key = file.Namespace + separator + path
+				treePath = append(treePath, file.Namespace+separator)
+			}
+
+			e, ok := byKey[key]
+			if !ok {
+				e = &entry{
+					key:       key,
+					namespace: file.Namespace,
+					path:      append(treePath, splitKeyPath(path)...),
+					values:    make(map[string]string),
+					issues:    make(map[string]bool),
+				}
+				byKey[key] = e
+			}
+			e.values[file.Locale] = value
+		}
+	}
+
+	// Mark the cells doctor complains about
+	results, err := doctor.Check(files, separator)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, res := range results {
+		var keys []string
+		keys = append(keys, res.MissingKeys...)
+		keys = append(keys, res.EmptyKeys...)
+		keys = append(keys, res.FuzzyKeys...)
+		for _, issue := range res.PlaceholderIssues {
+			keys = append(keys, issue.Key)
+		}
+		for _, issue := range res.TypeIssues {
+			keys = append(keys, issue.Key)
+		}
+		for _, k := range keys {
+			if e, ok := byKey[k]; ok {
+				e.issues[res.File.Locale] = true
+			}
+		}
+	}
+
+	entries := make([]*entry, 0, len(byKey))
+	for _, e := range byKey {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	locales, _ := i18n.GetLocaleList(files)
+	sort.Strings(locales)
+
+	return entries, locales, nil
+}
+
+// splitKeyPath splits a flattened key on unescaped dots and unescapes the parts
+func splitKeyPath(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			i++
+			cur.WriteByte(key[i])
+		case key[i] == '.':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(key[i])
+		}
+	}
+	return append(parts, cur.String())
+}
+
+// buildTree arranges sorted entries into a tree
+func buildTree(entries []*entry) *node {
+	root := &node{depth: -1}
+	index := make(map[string]*node)
+
+	for _, e := range entries {
+		parent := root
+		for i, name := range e.path {
+			id := strings.Join(e.path[:i+1], "\x00")
+			child, ok := index[id]
+			if !ok {
+				child = &node{name: name, id: id, depth: i}
+				index[id] = child
+				parent.children = append(parent.children, child)
+			}
+			parent = child
+		}
+		parent.entry = e
+	}
+
+	return root
+}
+
+// leaves returns the entries of a node and all its descendants
+func (n *node) leaves() []*entry {
+	var out []*entry
+	if n.entry != nil {
+		out = append(out, n.entry)
+	}
+	for _, c := range n.children {
+		out = append(out, c.leaves()...)
+	}
+	return out
+}
+
+// matches reports whether the node or any descendant has a matching entry
+func (n *node) matches(match func(*entry) bool) bool {
+	if n.entry != nil && match(n.entry) {
+		return true
+	}
+	for _, c := range n.children {
+		if c.matches(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleRows lists the nodes to display. Without a filter, children of
+// collapsed nodes are hidden; with a filter, every node leading to a matching
+// entry is shown.
+func visibleRows(root *node, expanded map[string]bool, match func(*entry) bool) []*node {
+	var rows []*node
+	var walk func(n *node)
+	walk = func(n *node) {
+		for _, c := range n.children {
+			if match != nil && !c.matches(match) {
+				continue
+			}
+			rows = append(rows, c)
+			if len(c.children) > 0 && (match != nil || expanded[c.id]) {
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+	return rows
+}
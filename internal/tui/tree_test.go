@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func TestSplitKeyPath(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"home.title", []string{"home", "title"}},
+		{"title", []string{"title"}},
+		{`File not found\.`, []string{"File not found."}},
+		{`menu.items.0`, []string{"menu", "items", "0"}},
+	}
+
+	for _, tt := range tests {
+		if got := splitKeyPath(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitKeyPath(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestCollectEntries(t *testing.T) {
+	files := []*types.I18nFile{
+		{Path: "en/common.json", Locale: "en", Namespace: "common", Data: `{"home": {"title": "Home", "desc": "Welcome"}}`},
+		{Path: "de/common.json", Locale: "de", Namespace: "common", Data: `{"home": {"title": ""}}`},
+	}
+
+	entries, locales, err := collectEntries(files, ":")
+	if err != nil {
+		t.Fatalf("collectEntries() error = %v", err)
+	}
+	if !reflect.DeepEqual(locales, []string{"de", "en"}) {
+		t.Errorf("collectEntries() locales = %v", locales)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("collectEntries() returned %d entries, want 2", len(entries))
+	}
+
+	desc := entries[0]
+	if desc.key != "common:home.desc" || !reflect.DeepEqual(desc.path, []string{"common:", "home", "desc"}) {
+		t.Errorf("entry = %s %v", desc.key, desc.path)
+	}
+	if !desc.issues["de"] || desc.issues["en"] {
+		t.Errorf("common:home.desc issues = %v, want missing in de", desc.issues)
+	}
+	if title := entries[1]; !title.issues["de"] {
+		t.Errorf("common:home.title issues = %v, want empty in de", title.issues)
+	}
+}
+
+func TestVisibleRows(t *testing.T) {
+	entries := []*entry{
+		{key: "a.x", path: []string{"a", "x"}},
+		{key: "a.y", path: []string{"a", "y"}, issues: map[string]bool{"de": true}},
+		{key: "b", path: []string{"b"}},
+	}
+	root := buildTree(entries)
+
+	names := func(rows []*node) []string {
+		var out []string
+		for _, n := range rows {
+			out = append(out, n.name)
+		}
+		return out
+	}
+
+	if got := names(visibleRows(root, map[string]bool{}, nil)); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("collapsed rows = %v", got)
+	}
+	if got := names(visibleRows(root, map[string]bool{"a": true}, nil)); !reflect.DeepEqual(got, []string{"a", "x", "y", "b"}) {
+		t.Errorf("expanded rows = %v", got)
+	}
+
+	// Filters show matching keys even inside collapsed nodes
+	issues := func(e *entry) bool { return e.hasIssues() }
+	if got := names(visibleRows(root, map[string]bool{}, issues)); !reflect.DeepEqual(got, []string{"a", "y"}) {
+		t.Errorf("filtered rows = %v", got)
+	}
+}
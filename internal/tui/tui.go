@@ -0,0 +1,59 @@
+// Package tui implements the interactive key browser started by "i18nedt tui".
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+// Action is what the user asked for when leaving the browser
+type Action int
+
+const (
+	// Quit leaves without writing inline edits
+	Quit Action = iota
+	// Save writes inline edits to disk
+	Save
+	// Edit opens the selected keys in $EDITOR
+	Edit
+)
+
+// Result is returned when the browser exits
+type Result struct {
+	Action Action
+	Keys   []string // Keys to edit for the Edit action
+}
+
+// State is kept across runs of the browser, so that it reopens where it was
+// left after saving or editing in $EDITOR
+type State struct {
+	Cursor     string          // Node under the cursor
+	Expanded   map[string]bool // Unfolded nodes
+	Search     string
+	IssuesOnly bool
+	Selected   map[string]bool // Selected keys
+	Status     string          // Shown in the status line when the browser reopens
+}
+
+// Run shows the browser for files until the user quits, saves or asks to
+// edit keys. Inline edits are applied to files and marked dirty; writing them
+// is left to the caller.
+func Run(files []*types.I18nFile, separator string, state *State) (Result, error) {
+	m, err := newModel(files, separator, state)
+	if err != nil {
+		return Result{}, err
+	}
+
+	m.zones = zone.New()
+	defer m.zones.Close()
+
+	final, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to run interface: %w", err)
+	}
+	return final.(*model).result, nil
+}
@@ -0,0 +1,429 @@
+package xliff
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kikyous/i18nedt/internal/flatten"
+	"github.com/kikyous/i18nedt/internal/i18n"
+	"github.com/kikyous/i18nedt/pkg/types"
+	"github.com/tidwall/gjson"
+)
+
+// Supported XLIFF versions
+const (
+	Version12 = "1.2"
+	Version20 = "2.0"
+)
+
+// Unit is a single translatable string
+type Unit struct {
+	ID     string // Display key including namespace, e.g. "common:home.title"
+	Source string
+	Target string
+	State  string
+}
+
+// Document is the version independent content of an XLIFF file
+type Document struct {
+	SourceLocale string
+	TargetLocale string
+	Units        []Unit
+	Namespaces   map[string]string // Unit ID -> namespace, used to group units into <file> elements
+}
+
+// Collect gathers the string leaves below the requested keys for a source and
+// target locale. With no keys every string in the source locale is collected.
+func Collect(files []*types.I18nFile, keys []string, source, target, separator string) (*Document, error) {
+	doc := &Document{
+		SourceLocale: source,
+		TargetLocale: target,
+		Namespaces:   make(map[string]string),
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if file.Locale != source {
+			continue
+		}
+
+		targetFile := findFile(files, target, file.Namespace)
+		prefix := ""
+		if file.Namespace != "" {
+			prefix = file.Namespace + separator
+		}
+
+		flat, err := flatten.FlattenJSON([]byte(file.Data), "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten file %s: %w", file.Path, err)
+		}
+
+		for key := range flat {
+			if !matchesKeys(key, file.Namespace, keys, separator) {
+				continue
+			}
+
+			src := gjson.Get(file.Data, key)
+			if src.Type != gjson.String {
+				continue // Only strings are translatable
+			}
+
+			id := prefix + key
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			unit := Unit{ID: id, Source: src.String()}
+			if targetFile != nil {
+				unit.Target = gjson.Get(targetFile.Data, key).String()
+			}
+			doc.Units = append(doc.Units, unit)
+			doc.Namespaces[id] = file.Namespace
+		}
+	}
+
+	if len(doc.Units) == 0 && !hasLocale(files, source) {
+		return nil, fmt.Errorf("no files found for source locale %s", source)
+	}
+
+	sort.Slice(doc.Units, func(i, j int) bool { return doc.Units[i].ID < doc.Units[j].ID })
+	return doc, nil
+}
+
+// matchesKeys reports whether a flattened key lies below one of the requested keys
+func matchesKeys(key, namespace string, keys []string, separator string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+
+	for _, k := range keys {
+		reqNs, reqKey := "", k
+		if parts := strings.SplitN(k, separator, 2); len(parts) == 2 {
+			reqNs, reqKey = parts[0], parts[1]
+		}
+		if reqNs != "" && reqNs != namespace {
+			continue
+		}
+		if key == reqKey || strings.HasPrefix(key, reqKey+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func findFile(files []*types.I18nFile, locale, namespace string) *types.I18nFile {
+	for _, f := range files {
+		if f.Locale == locale && f.Namespace == namespace {
+			return f
+		}
+	}
+	return nil
+}
+
+func hasLocale(files []*types.I18nFile, locale string) bool {
+	return i18n.FindFileByLocale(files, locale) != nil
+}
+
+// Marshal renders the document as XLIFF of the given version
+func Marshal(doc *Document, version string) ([]byte, error) {
+	var v interface{}
+	switch version {
+	case Version12, "":
+		v = toXLIFF12(doc)
+	case Version20:
+		v = toXLIFF20(doc)
+	default:
+		return nil, fmt.Errorf("unsupported XLIFF version %s (use %s or %s)", version, Version12, Version20)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("failed to encode XLIFF: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses XLIFF 1.2 or 2.0 content
+func Unmarshal(data []byte) (*Document, error) {
+	var probe struct {
+		Version string `xml:"version,attr"`
+	}
+	if err := xml.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid XLIFF: %w", err)
+	}
+
+	switch probe.Version {
+	case Version12:
+		var x xliff12
+		if err := xml.Unmarshal(data, &x); err != nil {
+			return nil, fmt.Errorf("invalid XLIFF 1.2: %w", err)
+		}
+		return x.document()
+	case Version20:
+		var x xliff20
+		if err := xml.Unmarshal(data, &x); err != nil {
+			return nil, fmt.Errorf("invalid XLIFF 2.0: %w", err)
+		}
+		return x.document()
+	default:
+		return nil, fmt.Errorf("unsupported XLIFF version %q", probe.Version)
+	}
+}
+
+// IsTranslated reports whether a unit state means the target may be written
+func IsTranslated(state string) bool {
+	switch state {
+	case "translated", "final", "signed-off", "reviewed":
+		return true
+	}
+	return false
+}
+
+// ToTempFile converts the translated units into edits for the target locale,
+// so they can be applied with editor.ApplyChanges. It also returns the IDs of
+// units skipped because of their state.
+func ToTempFile(doc *Document, separator string) (*types.TempFile, []string) {
+	temp := &types.TempFile{
+		Locales:   []string{doc.TargetLocale},
+		Content:   make(map[string]map[string]*types.Value),
+		Deletes:   []string{},
+		Separator: separator,
+	}
+
+	var skipped []string
+	for _, u := range doc.Units {
+		if !IsTranslated(u.State) {
+			skipped = append(skipped, u.ID)
+			continue
+		}
+		temp.Keys = append(temp.Keys, u.ID)
+		temp.Content[u.ID] = map[string]*types.Value{
+			doc.TargetLocale: types.NewStringValue(u.Target),
+		}
+	}
+
+	return temp, skipped
+}
+
+// text is the content of a <source> or <target> element. Inline markup
+// (placeholders, formatting) can't be mapped back to plain strings.
+type text struct {
+	Text   string `xml:",chardata"`
+	Inline []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+func (t *text) value(id string) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	if len(t.Inline) > 0 {
+		return "", fmt.Errorf("unit %s: inline markup is not supported", id)
+	}
+	return t.Text, nil
+}
+
+// XLIFF 1.2
+
+type xliff12 struct {
+	XMLName xml.Name      `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string        `xml:"version,attr"`
+	Files   []xliff12File `xml:"file"`
+}
+
+type xliff12File struct {
+	Original       string        `xml:"original,attr"`
+	SourceLanguage string        `xml:"source-language,attr"`
+	TargetLanguage string        `xml:"target-language,attr,omitempty"`
+	Datatype       string        `xml:"datatype,attr"`
+	Units          []xliff12Unit `xml:"body>trans-unit"`
+}
+
+type xliff12Unit struct {
+	ID     string         `xml:"id,attr"`
+	Source text           `xml:"source"`
+	Target *xliff12Target `xml:"target"`
+}
+
+type xliff12Target struct {
+	text
+	State string `xml:"state,attr,omitempty"`
+}
+
+func toXLIFF12(doc *Document) *xliff12 {
+	x := &xliff12{Version: Version12}
+	for _, ns := range namespaces(doc) {
+		f := xliff12File{
+			Original:       originalName(ns),
+			SourceLanguage: doc.SourceLocale,
+			TargetLanguage: doc.TargetLocale,
+			Datatype:       "plaintext",
+		}
+		for _, u := range unitsIn(doc, ns) {
+			state := "needs-translation"
+			if u.Target != "" {
+				state = "translated"
+			}
+			f.Units = append(f.Units, xliff12Unit{
+				ID:     u.ID,
+				Source: text{Text: u.Source},
+				Target: &xliff12Target{text: text{Text: u.Target}, State: state},
+			})
+		}
+		x.Files = append(x.Files, f)
+	}
+	return x
+}
+
+func (x *xliff12) document() (*Document, error) {
+	doc := &Document{Namespaces: make(map[string]string)}
+	for _, f := range x.Files {
+		if doc.SourceLocale == "" {
+			doc.SourceLocale = f.SourceLanguage
+		}
+		if doc.TargetLocale == "" {
+			doc.TargetLocale = f.TargetLanguage
+		}
+		for _, u := range f.Units {
+			source, err := u.Source.value(u.ID)
+			if err != nil {
+				return nil, err
+			}
+			unit := Unit{ID: u.ID, Source: source}
+			if u.Target != nil {
+				if unit.Target, err = u.Target.value(u.ID); err != nil {
+					return nil, err
+				}
+				unit.State = u.Target.State
+			}
+			doc.Units = append(doc.Units, unit)
+		}
+	}
+	return doc, nil
+}
+
+// XLIFF 2.0
+
+type xliff20 struct {
+	XMLName xml.Name      `xml:"urn:oasis:names:tc:xliff:document:2.0 xliff"`
+	Version string        `xml:"version,attr"`
+	SrcLang string        `xml:"srcLang,attr"`
+	TrgLang string        `xml:"trgLang,attr,omitempty"`
+	Files   []xliff20File `xml:"file"`
+}
+
+type xliff20File struct {
+	ID    string        `xml:"id,attr"`
+	Units []xliff20Unit `xml:"unit"`
+}
+
+type xliff20Unit struct {
+	ID       string           `xml:"id,attr"`
+	Segments []xliff20Segment `xml:"segment"`
+}
+
+type xliff20Segment struct {
+	State  string `xml:"state,attr,omitempty"`
+	Source text   `xml:"source"`
+	Target *text  `xml:"target"`
+}
+
+func toXLIFF20(doc *Document) *xliff20 {
+	x := &xliff20{Version: Version20, SrcLang: doc.SourceLocale, TrgLang: doc.TargetLocale}
+	for _, ns := range namespaces(doc) {
+		f := xliff20File{ID: originalName(ns)}
+		for _, u := range unitsIn(doc, ns) {
+			state := "initial"
+			if u.Target != "" {
+				state = "translated"
+			}
+			f.Units = append(f.Units, xliff20Unit{
+				ID: u.ID,
+				Segments: []xliff20Segment{{
+					State:  state,
+					Source: text{Text: u.Source},
+					Target: &text{Text: u.Target},
+				}},
+			})
+		}
+		x.Files = append(x.Files, f)
+	}
+	return x
+}
+
+func (x *xliff20) document() (*Document, error) {
+	doc := &Document{
+		SourceLocale: x.SrcLang,
+		TargetLocale: x.TrgLang,
+		Namespaces:   make(map[string]string),
+	}
+	for _, f := range x.Files {
+		for _, u := range f.Units {
+			unit := Unit{ID: u.ID, State: "initial"}
+			// Segments of a unit are joined; the unit is only as translated as its least translated segment
+			for i, seg := range u.Segments {
+				source, err := seg.Source.value(u.ID)
+				if err != nil {
+					return nil, err
+				}
+				target, err := seg.Target.value(u.ID)
+				if err != nil {
+					return nil, err
+				}
+				unit.Source += source
+				unit.Target += target
+
+				state := seg.State
+				if state == "" {
+					state = "initial"
+				}
+				if i == 0 || !IsTranslated(state) {
+					unit.State = state
+				}
+			}
+			doc.Units = append(doc.Units, unit)
+		}
+	}
+	return doc, nil
+}
+
+// namespaces returns the sorted namespaces used by the document's units
+func namespaces(doc *Document) []string {
+	set := make(map[string]bool)
+	for _, u := range doc.Units {
+		set[doc.Namespaces[u.ID]] = true
+	}
+	list := make([]string, 0, len(set))
+	for ns := range set {
+		list = append(list, ns)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func unitsIn(doc *Document, ns string) []Unit {
+	var units []Unit
+	for _, u := range doc.Units {
+		if doc.Namespaces[u.ID] == ns {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// originalName names the <file> element of a namespace
+func originalName(ns string) string {
+	if ns == "" {
+		return "messages"
+	}
+	return ns
+}
@@ -0,0 +1,117 @@
+package xliff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kikyous/i18nedt/pkg/types"
+)
+
+func testFiles() []*types.I18nFile {
+	return []*types.I18nFile{
+		{Path: "en-US/common.json", Locale: "en-US", Namespace: "common", Data: `{"home": {"title": "Home", "count": 3, "intro": "Tom & Jerry"}, "nav": {"about": "About"}}`},
+		{Path: "de-DE/common.json", Locale: "de-DE", Namespace: "common", Data: `{"home": {"title": "Startseite"}}`},
+		{Path: "en-US/auth.json", Locale: "en-US", Namespace: "auth", Data: `{"home": {"login": "Log in"}}`},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	doc, err := Collect(testFiles(), []string{"common:home"}, "en-US", "de-DE", ":")
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	want := []Unit{
+		{ID: "common:home.intro", Source: "Tom & Jerry"},
+		{ID: "common:home.title", Source: "Home", Target: "Startseite"},
+	}
+	if len(doc.Units) != len(want) {
+		t.Fatalf("Collect() units = %+v, want %+v", doc.Units, want)
+	}
+	for i := range want {
+		if doc.Units[i] != want[i] {
+			t.Errorf("Collect() unit %d = %+v, want %+v", i, doc.Units[i], want[i])
+		}
+	}
+
+	// Without namespace, the key matches in every namespace
+	doc, _ = Collect(testFiles(), []string{"home"}, "en-US", "de-DE", ":")
+	if len(doc.Units) != 3 {
+		t.Errorf("Collect() without namespace units = %+v, want 3", doc.Units)
+	}
+
+	if _, err := Collect(testFiles(), nil, "fr-FR", "de-DE", ":"); err == nil {
+		t.Error("Collect() expected error for unknown source locale")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, version := range []string{Version12, Version20} {
+		t.Run(version, func(t *testing.T) {
+			doc, err := Collect(testFiles(), nil, "en-US", "de-DE", ":")
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+
+			data, err := Marshal(doc, version)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !strings.Contains(string(data), "Tom &amp; Jerry") {
+				t.Errorf("Marshal() should escape XML:\n%s", data)
+			}
+
+			parsed, err := Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if parsed.SourceLocale != "en-US" || parsed.TargetLocale != "de-DE" {
+				t.Errorf("Unmarshal() locales = %s -> %s", parsed.SourceLocale, parsed.TargetLocale)
+			}
+			if len(parsed.Units) != len(doc.Units) {
+				t.Fatalf("Unmarshal() units = %d, want %d", len(parsed.Units), len(doc.Units))
+			}
+
+			// Only units that already had a target are marked translated
+			temp, skipped := ToTempFile(parsed, ":")
+			if len(temp.Content) != 1 || temp.Content["common:home.title"]["de-DE"].Value != "Startseite" {
+				t.Errorf("ToTempFile() content = %v", temp.Content)
+			}
+			if len(skipped) != 3 {
+				t.Errorf("ToTempFile() skipped = %v, want 3", skipped)
+			}
+		})
+	}
+}
+
+func TestUnmarshalStates(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<xliff version="1.2" xmlns="urn:oasis:names:tc:xliff:document:1.2">
+  <file original="messages" source-language="en-US" target-language="fr-FR" datatype="plaintext">
+    <body>
+      <trans-unit id="a"><source>A</source><target state="final">Á</target></trans-unit>
+      <trans-unit id="b"><source>B</source><target state="needs-review-translation">B?</target></trans-unit>
+      <trans-unit id="c"><source>C</source><target>C</target></trans-unit>
+      <trans-unit id="d"><source>D</source><target state="translated">Dé</target></trans-unit>
+    </body>
+  </file>
+</xliff>`
+
+	doc, err := Unmarshal([]byte(data))
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	temp, skipped := ToTempFile(doc, ":")
+	if len(temp.Content) != 2 || temp.Content["a"]["fr-FR"].Value != "Á" || temp.Content["d"]["fr-FR"].Value != "Dé" {
+		t.Errorf("ToTempFile() content = %v", temp.Content)
+	}
+	if strings.Join(skipped, ",") != "b,c" {
+		t.Errorf("ToTempFile() skipped = %v, want [b c]", skipped)
+	}
+
+	inline := strings.Replace(data, "<target state=\"final\">Á</target>", "<target state=\"final\">Á <x id=\"1\"/></target>", 1)
+	if _, err := Unmarshal([]byte(inline)); err == nil {
+		t.Error("Unmarshal() expected error for inline markup")
+	}
+}
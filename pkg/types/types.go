@@ -29,14 +29,28 @@ func NewJSONValue(v string) *Value {
 
 // Config holds application configuration
 type Config struct {
-	Files     []string
-	Keys      []string
-	Editor    string
-	PrintOnly bool
-	NoTips    bool
-	Flatten   bool
-	Doctor    bool
-	Separator string
+	Files          []string
+	Keys           []string
+	Editor         string
+	PrintOnly      bool
+	NoTips         bool
+	Flatten        bool
+	Doctor         bool
+	Fix            bool // Edit the keys the doctor reports instead of printing them
+	FillFromTM     bool // Fill empty values with exact translation memory matches
+	Separator      string
+	Resume         string
+	Apply          string   // Temporary file applied without an editor, "-" for stdin
+	DryRun         bool     // Show the changes instead of saving them
+	Confirm        bool     // Show the changes and ask before saving them
+	Source         []string // Source code patterns scanned for key usage by the doctor
+	Functions      []string // Translation function names looked for in source code
+	SourceLocale   string   // Locale the doctor compares translations against
+	Placeholders   []string // Placeholder syntaxes compared by the doctor
+	AllowIdentical []string // Values the doctor accepts as identical to the source locale
+	Format         string   // Doctor report format
+	Baseline       string   // Baseline file of known doctor issues, empty to report all issues
+	WriteBaseline  string   // File to record the current doctor issues in
 }
 
 // I18nFile represents a single i18n JSON file
@@ -46,6 +60,25 @@ type I18nFile struct {
 	Locale    string
 	Namespace string
 	Dirty     bool
+	Original  string // Data as loaded from disk, base for three-way merges
+	Hash      string // Content hash of the file on disk at load time, empty if not tracked
+	Style     FileStyle
+	State     interface{} // Format-specific data needed to write the file back
+}
+
+// FileStyle describes how a file is formatted on disk so it can be written back the same way
+type FileStyle struct {
+	Indent        string // One level of indentation, empty for single-line files
+	LineEnding    string // "\n" or "\r\n"
+	FinalNewline  bool   // File ends with a line ending
+	BOM           bool   // File starts with a UTF-8 byte order mark
+	EscapeUnicode bool   // Non-ASCII characters are written as \uXXXX escapes
+	EscapeHTML    bool   // <, > and & are written as \u003c, \u003e and \u0026
+}
+
+// DefaultFileStyle returns the style used for files that don't exist yet
+func DefaultFileStyle() FileStyle {
+	return FileStyle{Indent: "  ", LineEnding: "\n"}
 }
 
 // TempFile represents the temporary edit file
@@ -55,7 +88,15 @@ type TempFile struct {
 	Locales   []string
 	Content   map[string]map[string]*Value // key -> locale -> *Value
 	Deletes   []string                     // keys to delete
+	Renames   []KeyRename                  // keys to move, applied before deletes and updates
 	Separator string
+	Context   map[string]string // key -> read-only context shown as comments (e.g. descriptions)
+}
+
+// KeyRename moves a key with its values in every locale, possibly to another namespace
+type KeyRename struct {
+	From string
+	To   string
 }
 
 // KeyOperation represents an operation to perform on a key